@@ -0,0 +1,173 @@
+package admin_controller
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	dataprovider "github.com/hyphenXY/Streak-App/internal/dataproviders"
+	middlewares "github.com/hyphenXY/Streak-App/internal/middleware"
+	"github.com/hyphenXY/Streak-App/internal/models"
+)
+
+// GET /admin/classes/:classId/codes?includeRevoked=true
+func ListClassCodes(c *gin.Context) {
+	classID, exists := middlewares.GetClassID(c)
+	if !exists {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "classID not provided"})
+		return
+	}
+
+	codes, err := dataprovider.ListClassCodes(classID, c.Query("includeRevoked") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch class codes"})
+		return
+	}
+
+	now := time.Now()
+	list := make([]gin.H, 0, len(codes))
+	for _, code := range codes {
+		list = append(list, classCodeResponse(code, now))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"class_id": classID, "codes": list})
+}
+
+// POST /admin/classes/:classId/codes
+func CreateClassCode(c *gin.Context) {
+	principal, exists := middlewares.GetPrincipal(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	classID, exists := middlewares.GetClassID(c)
+	if !exists {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "classID not provided"})
+		return
+	}
+
+	// 1️⃣ Parse JSON body
+	var req struct {
+		Label     string     `json:"label" binding:"max=50"`
+		ExpiresAt *time.Time `json:"expiresAt"`
+		MaxUses   *int       `json:"maxUses" binding:"omitempty,min=1"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expiresAt must be in the future"})
+		return
+	}
+
+	// 2️⃣ Issue the code
+	code, err := dataprovider.CreateClassCode(classID, dataprovider.ClassCodeOptions{
+		Label:     strings.TrimSpace(req.Label),
+		ExpiresAt: req.ExpiresAt,
+		MaxUses:   req.MaxUses,
+	}, principal.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create class code"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Class code created", "code": classCodeResponse(*code, time.Now())})
+}
+
+// POST /admin/classes/:classId/codes/:codeId/rotate
+//
+// The new code keeps the old one's expiry unless the optional body sets a new
+// expiresAt, which an expired code needs.
+func RotateClassCode(c *gin.Context) {
+	principal, exists := middlewares.GetPrincipal(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	classID, exists := middlewares.GetClassID(c)
+	if !exists {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "classID not provided"})
+		return
+	}
+	codeID, err := strconv.ParseUint(c.Param("codeId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid code ID"})
+		return
+	}
+
+	var req struct {
+		ExpiresAt *time.Time `json:"expiresAt"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expiresAt must be in the future"})
+		return
+	}
+
+	code, err := dataprovider.RotateClassCode(classID, uint(codeID), req.ExpiresAt, principal.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, dataprovider.ErrClassCodeNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Class code not found"})
+		case errors.Is(err, dataprovider.ErrClassCodeInactive):
+			c.JSON(http.StatusConflict, gin.H{"error": "Class code has expired; pass a new expiresAt"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rotate class code"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Class code rotated", "code": classCodeResponse(*code, time.Now())})
+}
+
+// DELETE /admin/classes/:classId/codes/:codeId
+func RevokeClassCode(c *gin.Context) {
+	principal, exists := middlewares.GetPrincipal(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	classID, exists := middlewares.GetClassID(c)
+	if !exists {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "classID not provided"})
+		return
+	}
+	codeID, err := strconv.ParseUint(c.Param("codeId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid code ID"})
+		return
+	}
+
+	if err := dataprovider.RevokeClassCode(classID, uint(codeID), principal.ID); err != nil {
+		if errors.Is(err, dataprovider.ErrClassCodeNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Class code not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke class code"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Class code revoked", "code_id": codeID})
+}
+
+func classCodeResponse(code models.ClassCode, now time.Time) gin.H {
+	return gin.H{
+		"id":         code.ID,
+		"code":       code.Code,
+		"label":      code.Label,
+		"expires_at": code.ExpiresAt,
+		"max_uses":   code.MaxUses,
+		"uses":       code.Uses,
+		"revoked_at": code.RevokedAt,
+		"active":     code.Active(now),
+		"created_at": code.CreatedAt,
+	}
+}
@@ -0,0 +1,168 @@
+package admin_controller
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"image/png"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/qr"
+	"github.com/gin-gonic/gin"
+	dataprovider "github.com/hyphenXY/Streak-App/internal/dataproviders"
+	middlewares "github.com/hyphenXY/Streak-App/internal/middleware"
+	"github.com/hyphenXY/Streak-App/internal/utils"
+)
+
+const (
+	defaultInviteMinutes = 60
+	maxInviteMinutes     = 7 * 24 * 60
+	defaultQRCodeSize    = 512
+	minQRCodeSize        = 128
+	maxQRCodeSize        = 2048
+)
+
+// POST /admin/classes/:classId/invites?format=png&size=512
+//
+// Issues a signed invite that enrolls whoever opens it, until it expires, the
+// class's invites are revoked or the issuing admin loses roster access. The
+// response carries the invite URL and a QR code of it; with format=png the QR
+// code is returned as the image itself, for putting straight on a projector.
+//
+// Invite URLs point at CLASS_INVITE_BASE_URL with the token appended: the
+// app's landing page, which signs the student in and posts the token to
+// /user/enroll/invite/:token. Invites can't be issued until it is set.
+func CreateClassInvite(c *gin.Context) {
+	principal, exists := middlewares.GetPrincipal(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	classID, exists := middlewares.GetClassID(c)
+	if !exists {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "classID not provided"})
+		return
+	}
+
+	// 1️⃣ Parse the optional JSON body and QR code size
+	var req struct {
+		ExpiresInMinutes *int `json:"expiresInMinutes"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	minutes := defaultInviteMinutes
+	if req.ExpiresInMinutes != nil {
+		minutes = *req.ExpiresInMinutes
+	}
+	if minutes < 1 || minutes > maxInviteMinutes {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expiresInMinutes must be between 1 and 10080"})
+		return
+	}
+
+	size := defaultQRCodeSize
+	if s := c.Query("size"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < minQRCodeSize || n > maxQRCodeSize {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "size must be between 128 and 2048"})
+			return
+		}
+		size = n
+	}
+
+	baseURL := strings.TrimRight(strings.TrimSpace(os.Getenv("CLASS_INVITE_BASE_URL")), "/")
+	if baseURL == "" {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Class invites are not configured"})
+		return
+	}
+
+	// 2️⃣ Sign the invite and render it
+	epoch, err := dataprovider.GetClassInviteEpoch(classID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create invite"})
+		return
+	}
+	expiresAt := time.Now().Add(time.Duration(minutes) * time.Minute)
+	token, err := utils.GenerateClassInviteToken(classID, principal.ID, epoch, expiresAt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create invite"})
+		return
+	}
+	inviteURL := baseURL + "/" + token
+
+	qrCode, err := renderQRCode(inviteURL, size)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render QR code"})
+		return
+	}
+
+	if err := dataprovider.RecordClassInviteIssued(classID, principal.ID, expiresAt); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create invite"})
+		return
+	}
+
+	// 3️⃣ Respond with the image or the JSON description
+	if c.Query("format") == "png" {
+		c.Header("Cache-Control", "no-store")
+		c.Header("X-Invite-URL", inviteURL)
+		c.Header("X-Invite-Expires-At", expiresAt.UTC().Format(time.RFC3339))
+		c.Data(http.StatusCreated, "image/png", qrCode)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":    "Invite created",
+		"class_id":   classID,
+		"token":      token,
+		"url":        inviteURL,
+		"expires_at": expiresAt,
+		"qr_code":    "data:image/png;base64," + base64.StdEncoding.EncodeToString(qrCode),
+	})
+}
+
+// DELETE /admin/classes/:classId/invites
+//
+// Voids every invite to the class issued so far, e.g. when a QR code has been
+// shared beyond the room. Invites issued afterwards work as usual.
+func RevokeClassInvites(c *gin.Context) {
+	principal, exists := middlewares.GetPrincipal(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	classID, exists := middlewares.GetClassID(c)
+	if !exists {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "classID not provided"})
+		return
+	}
+
+	if err := dataprovider.RevokeClassInvites(classID, principal.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke invites"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Class invites revoked", "class_id": classID})
+}
+
+func renderQRCode(content string, size int) ([]byte, error) {
+	code, err := qr.Encode(content, qr.M, qr.Auto)
+	if err != nil {
+		return nil, err
+	}
+	code, err = barcode.Scale(code, size, size)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, code); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,158 @@
+package admin_controller
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	dataprovider "github.com/hyphenXY/Streak-App/internal/dataproviders"
+	middlewares "github.com/hyphenXY/Streak-App/internal/middleware"
+	"github.com/hyphenXY/Streak-App/internal/utils"
+)
+
+// PATCH /admin/classes/:classId
+func UpdateClass(c *gin.Context) {
+	principal, exists := middlewares.GetPrincipal(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	classID, exists := middlewares.GetClassID(c)
+	if !exists {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "classID not provided"})
+		return
+	}
+
+	// 1️⃣ Parse JSON body; only the fields sent are changed
+	var req struct {
+		Name  *string `json:"name" binding:"omitempty,max=50"`
+		Email *string `json:"email" binding:"omitempty,max=100"`
+		Phone *string `json:"phone"`
+		// RequireApproval makes new enrollments wait for an admin
+		RequireApproval *bool `json:"requireApproval"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 2️⃣ Validate and collect updates
+	updates := map[string]interface{}{}
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Class name can't be empty"})
+			return
+		}
+		updates["name"] = name
+	}
+	if req.Email != nil {
+		updates["email"] = strings.TrimSpace(*req.Email)
+	}
+	if req.Phone != nil {
+		phone := ""
+		if *req.Phone != "" {
+			normalized, err := utils.NormalizePhone(*req.Phone)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid phone number"})
+				return
+			}
+			phone = normalized
+		}
+		updates["phone"] = phone
+	}
+	if req.RequireApproval != nil {
+		updates["require_approval"] = *req.RequireApproval
+	}
+	if len(updates) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nothing to update"})
+		return
+	}
+
+	// 3️⃣ Apply
+	class, err := dataprovider.UpdateClass(classID, updates, principal.ID, "admin")
+	if err != nil {
+		classLifecycleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":          "Class updated",
+		"class_id":         class.ID,
+		"name":             class.Name,
+		"email":            class.Email,
+		"phone":            class.Phone,
+		"require_approval": class.RequireApproval,
+	})
+}
+
+// POST /admin/classes/:classId/archive
+func ArchiveClass(c *gin.Context) {
+	setClassArchived(c, true)
+}
+
+// POST /admin/classes/:classId/restore
+func RestoreClass(c *gin.Context) {
+	setClassArchived(c, false)
+}
+
+func setClassArchived(c *gin.Context, archived bool) {
+	principal, exists := middlewares.GetPrincipal(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	classID, exists := middlewares.GetClassID(c)
+	if !exists {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "classID not provided"})
+		return
+	}
+
+	if err := dataprovider.SetClassArchived(classID, archived, principal.ID, "admin"); err != nil {
+		classLifecycleError(c, err)
+		return
+	}
+
+	message := "Class restored"
+	if archived {
+		message = "Class archived"
+	}
+	c.JSON(http.StatusOK, gin.H{"message": message, "class_id": classID, "archived": archived})
+}
+
+// DELETE /admin/classes/:classId
+//
+// Only archived classes can be deleted, so a class is never lost in one step.
+func DeleteClass(c *gin.Context) {
+	principal, exists := middlewares.GetPrincipal(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	classID, exists := middlewares.GetClassID(c)
+	if !exists {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "classID not provided"})
+		return
+	}
+
+	if err := dataprovider.DeleteClass(classID, principal.ID, "admin"); err != nil {
+		classLifecycleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Class deleted", "class_id": classID})
+}
+
+func classLifecycleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, dataprovider.ErrClassNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Class not found"})
+	case errors.Is(err, dataprovider.ErrClassArchived):
+		c.JSON(http.StatusConflict, gin.H{"error": "Class is archived"})
+	case errors.Is(err, dataprovider.ErrClassNotArchived):
+		c.JSON(http.StatusConflict, gin.H{"error": "Class is not archived"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update class"})
+	}
+}
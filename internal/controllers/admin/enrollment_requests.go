@@ -0,0 +1,144 @@
+package admin_controller
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	dataprovider "github.com/hyphenXY/Streak-App/internal/dataproviders"
+	middlewares "github.com/hyphenXY/Streak-App/internal/middleware"
+	"github.com/hyphenXY/Streak-App/internal/models"
+)
+
+// maxReviewBatch caps how many requests one approve or reject call handles.
+const maxReviewBatch = 200
+
+// GET /admin/classes/:classId/enrollmentRequests?status=pending
+func ListEnrollmentRequests(c *gin.Context) {
+	classID, exists := middlewares.GetClassID(c)
+	if !exists {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "classID not provided"})
+		return
+	}
+
+	status := c.DefaultQuery("status", models.EnrollmentRequestPending)
+	if status == "all" {
+		status = ""
+	} else if status != models.EnrollmentRequestPending &&
+		status != models.EnrollmentRequestApproved &&
+		status != models.EnrollmentRequestRejected {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be pending, approved, rejected or all"})
+		return
+	}
+
+	requests, err := dataprovider.ListEnrollmentRequests(classID, status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch enrollment requests"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"class_id": classID, "requests": requests})
+}
+
+// POST /admin/classes/:classId/enrollmentRequests/approve
+func ApproveEnrollmentRequests(c *gin.Context) {
+	reviewEnrollmentRequests(c, true)
+}
+
+// POST /admin/classes/:classId/enrollmentRequests/reject
+func RejectEnrollmentRequests(c *gin.Context) {
+	reviewEnrollmentRequests(c, false)
+}
+
+func reviewEnrollmentRequests(c *gin.Context, approve bool) {
+	principal, exists := middlewares.GetPrincipal(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	classID, exists := middlewares.GetClassID(c)
+	if !exists {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "classID not provided"})
+		return
+	}
+
+	// 1️⃣ Parse JSON body
+	var req struct {
+		RequestIDs []uint `json:"requestIds" binding:"required,min=1"`
+		Reason     string `json:"reason" binding:"max=255"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.RequestIDs) > maxReviewBatch {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Too many requests in one call", "max": maxReviewBatch})
+		return
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if !approve && reason == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A reason is required when rejecting"})
+		return
+	}
+
+	// 2️⃣ Review the pending ones
+	reviewed, err := dataprovider.ReviewEnrollmentRequests(classID, req.RequestIDs, approve, reason, principal.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to review enrollment requests"})
+		return
+	}
+
+	skipped := make([]uint, 0)
+	for _, id := range req.RequestIDs {
+		if !slices.Contains(reviewed, id) && !slices.Contains(skipped, id) {
+			skipped = append(skipped, id)
+		}
+	}
+
+	status := models.EnrollmentRequestRejected
+	if approve {
+		status = models.EnrollmentRequestApproved
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"class_id": classID,
+		"status":   status,
+		"reviewed": reviewed,
+		// Not pending requests of this class
+		"skipped": skipped,
+	})
+}
+
+// DELETE /admin/classes/:classId/students/:userId
+//
+// The student's attendance is kept; they can rejoin with a class code.
+func RemoveStudent(c *gin.Context) {
+	principal, exists := middlewares.GetPrincipal(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	classID, exists := middlewares.GetClassID(c)
+	if !exists {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "classID not provided"})
+		return
+	}
+	userID, err := strconv.ParseUint(c.Param("userId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := dataprovider.DeactivateEnrollment(uint(userID), classID, &principal.ID); err != nil {
+		if errors.Is(err, dataprovider.ErrNotEnrolled) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Student not enrolled in this class"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove student"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Student removed", "class_id": classID, "user_id": userID})
+}
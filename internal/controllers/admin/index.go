@@ -1,10 +1,7 @@
 package admin_controller
 
 import (
-	"bytes"
-	"encoding/json"
 	"errors"
-	"io"
 	"net/http"
 	"os"
 	"strconv"
@@ -12,8 +9,11 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	auth_controller "github.com/hyphenXY/Streak-App/internal/controllers/auth"
 	dataprovider "github.com/hyphenXY/Streak-App/internal/dataproviders"
+	middlewares "github.com/hyphenXY/Streak-App/internal/middleware"
 	"github.com/hyphenXY/Streak-App/internal/models"
+	"github.com/hyphenXY/Streak-App/internal/otp"
 	"github.com/hyphenXY/Streak-App/internal/utils"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
@@ -23,8 +23,9 @@ import (
 func SignIn(c *gin.Context) {
 	// 1️⃣ Parse JSON body
 	type SignInRequest struct {
-		UserName string `json:"userName" binding:"required"`
-		Password string `json:"password" binding:"required"`
+		UserName   string `json:"userName" binding:"required"`
+		Password   string `json:"password" binding:"required"`
+		DeviceName string `json:"deviceName"`
 	}
 
 	var req SignInRequest
@@ -33,52 +34,215 @@ func SignIn(c *gin.Context) {
 		return
 	}
 
+	// 2️⃣ Unknown usernames go through the same lockout and bcrypt work as
+	// wrong passwords, so neither can be used to probe for accounts
+	req.UserName = auth_controller.NormalizeUserName(req.UserName)
+	lockoutKey := auth_controller.UserNameSignInKey(req.UserName)
+
 	var user models.Admin
 	if err := dataprovider.DB.Where("user_name = ?", req.UserName).First(&user).Error; err != nil {
-		// User not found
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid username! try to remember it."})
+		if auth_controller.CheckSignInAllowed(c, "admin", lockoutKey, 0) {
+			auth_controller.DummyPasswordCheck(req.Password)
+			auth_controller.RejectSignIn(c, "admin", lockoutKey, 0, "unknown username")
+		}
+		return
+	}
+
+	if !auth_controller.CheckSignInAllowed(c, "admin", lockoutKey, user.ID) {
+		return
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		auth_controller.RejectSignIn(c, "admin", lockoutKey, user.ID, "wrong password")
 		return
 	}
+	auth_controller.SignInSucceeded(c)
 
-	err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password))
+	if user.SuspendedAt != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Admin account suspended"})
+		return
+	}
+
+	if auth_controller.ChallengeTwoFactor(c, user.ID, "admin", req.DeviceName) {
+		return
+	}
+
+	accessToken, err := auth_controller.StartSession(c, user.ID, "admin", req.DeviceName)
 	if err != nil {
-		// Wrong password
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid password! work your brain or reset it."})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start session"})
 		return
 	}
 
-	accessToken, err := utils.GenerateJWT(map[string]any{
-		"userId": user.ID,
-		"role":   "admin",
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Sign in successful",
+		"role":         "admin",
+		"access_token": accessToken,
+		"user": gin.H{
+			"id":        user.ID,
+			"username":  user.UserName,
+			"email":     user.Email,
+			"firstName": user.FirstName,
+			"lastName":  user.LastName,
+			"phone":     user.Phone,
+		},
 	})
+}
+
+// POST /admin/signIn/otp/send
+//
+// Sends a login OTP if an admin is registered to the phone. The response is
+// the same either way so it can't be used to probe for accounts. Only served
+// when ADMIN_OTP_SIGNIN is enabled.
+func SendSignInOTP(c *gin.Context) {
+	if !otpSignInEnabled() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "OTP sign-in is disabled for admins"})
+		return
+	}
+
+	type SendSignInOTPRequest struct {
+		Phone string `json:"phone" binding:"required"`
+	}
+
+	var req SendSignInOTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	phone, err := utils.NormalizePhone(req.Phone)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid phone number"})
+		return
+	}
+	req.Phone = phone
+
+	account, err := dataprovider.GetAdminByPhone(dataprovider.DB, req.Phone)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+		return
+	}
+
+	if account != nil {
+		// Codes only go by email to an address that has been verified
+		email := ""
+		if account.EmailVerifiedAt != nil {
+			email = account.Email
+		}
+		if err := otp.SendVia(c.Request.Context(), "admin", account.OTPChannel, req.Phone, email, models.OTPPurposeLogin, c.ClientIP()); err != nil {
+			var limited *otp.RateLimitError
+			switch {
+			case errors.As(err, &limited):
+				c.Header("Retry-After", strconv.Itoa(limited.RetryAfterSeconds()))
+				c.JSON(http.StatusTooManyRequests, gin.H{"error": limited.Error(), "retry_after": limited.RetryAfterSeconds()})
+			case errors.Is(err, otp.ErrInvalidPhone):
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid phone number"})
+			case errors.Is(err, otp.ErrDeliveryFailed):
+				c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to send OTP"})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store OTP"})
+			}
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "If an account uses this phone, an OTP has been sent",
+		"purpose": models.OTPPurposeLogin,
+	})
+}
+
+// POST /admin/signIn/otp
+//
+// Passwordless sign-in: verifies the login OTP sent by signIn/otp/send and
+// starts a session exactly like SignIn. Only served when ADMIN_OTP_SIGNIN is
+// enabled.
+func SignInWithOTP(c *gin.Context) {
+	if !otpSignInEnabled() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "OTP sign-in is disabled for admins"})
+		return
+	}
+
+	// 1️⃣ Parse JSON body
+	type SignInWithOTPRequest struct {
+		Phone      string `json:"phone" binding:"required"`
+		OTP        string `json:"otp" binding:"required"`
+		DeviceName string `json:"deviceName"`
+	}
+
+	var req SignInWithOTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	phone, err := utils.NormalizePhone(req.Phone)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create token"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid phone number"})
+		return
+	}
+	req.Phone = phone
+
+	// 2️⃣ Wrong codes count against the sign-in lockout like wrong passwords,
+	// keyed on the phone
+	lockoutKey := auth_controller.PhoneSignInKey(req.Phone)
+	var subjectID uint
+	if account, err := dataprovider.GetAdminByPhone(dataprovider.DB, req.Phone); err == nil {
+		subjectID = account.ID
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+		return
+	}
+	if !auth_controller.CheckSignInAllowed(c, "admin", lockoutKey, subjectID) {
 		return
 	}
 
-	refreshToken, err := utils.GenerateRefreshToken()
+	// 3️⃣ Verify the code and spend the resulting ticket straight away
+	var user *models.Admin
+	ticket, err := otp.Verify("admin", req.Phone, models.OTPPurposeLogin, req.OTP)
+	if err == nil {
+		err = otp.Redeem("admin", req.Phone, models.OTPPurposeLogin, ticket, func(tx *gorm.DB) error {
+			var err error
+			user, err = dataprovider.GetAdminByPhone(tx, req.Phone)
+			return err
+		})
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create refresh token"})
+		var limited *otp.RateLimitError
+		switch {
+		case errors.As(err, &limited):
+			auth_controller.RecordSignInFailure(c, "admin", lockoutKey, subjectID, "OTP locked")
+			c.Header("Retry-After", strconv.Itoa(limited.RetryAfterSeconds()))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": limited.Error(), "retry_after": limited.RetryAfterSeconds()})
+		case errors.Is(err, otp.ErrInvalidPhone):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid phone number"})
+		case errors.Is(err, otp.ErrExpiredOTP):
+			auth_controller.RecordSignInFailure(c, "admin", lockoutKey, subjectID, "OTP expired")
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "OTP expired"})
+		case errors.Is(err, otp.ErrInvalidOTP), errors.Is(err, otp.ErrInvalidTicket), errors.Is(err, gorm.ErrRecordNotFound):
+			auth_controller.RecordSignInFailure(c, "admin", lockoutKey, subjectID, "wrong OTP")
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid OTP"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "OTP sign-in failed"})
+		}
+		return
+	}
+	auth_controller.SignInSucceeded(c)
+
+	if user.SuspendedAt != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Admin account suspended"})
 		return
 	}
 
-	refreshTokenExpiry := time.Now().Add(30 * 24 * time.Hour) // 30 days
-	if err := dataprovider.UpdateAdminRefreshToken(user.ID, refreshToken, refreshTokenExpiry); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update refresh token"})
+	// 4️⃣ Start a session, or ask for the second factor first
+	if auth_controller.ChallengeTwoFactor(c, user.ID, "admin", req.DeviceName) {
 		return
 	}
 
-	// Set refresh token as HttpOnly cookie
-	secure := gin.Mode() == gin.ReleaseMode
-	c.SetCookie(
-		"refresh_token",
-		refreshToken,
-		int((30 * 24 * time.Hour).Seconds()), // expiry in seconds
-		"/",
-		"",     // domain (empty = current domain)
-		secure, // secure (true = HTTPS only)
-		true,   // httpOnly
-	)
+	accessToken, err := auth_controller.StartSession(c, user.ID, "admin", req.DeviceName)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start session"})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message":      "Sign in successful",
@@ -95,6 +259,13 @@ func SignIn(c *gin.Context) {
 	})
 }
 
+// otpSignInEnabled reports whether admins may sign in with a phone OTP
+// instead of their password. It is off unless ADMIN_OTP_SIGNIN is true.
+func otpSignInEnabled() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv("ADMIN_OTP_SIGNIN"))
+	return enabled
+}
+
 // POST /user/signUp
 func SignUp(c *gin.Context) {
 	// 1️⃣ Parse JSON body
@@ -106,7 +277,8 @@ func SignUp(c *gin.Context) {
 		LastName  string `json:"lastName" binding:"required"`
 		Phone     string `json:"phone" binding:"required"`
 		DoB       string `json:"dob" binding:"required"`
-		OTP       string `json:"otp" binding:"required"`
+		// Returned by verifyOTP for purpose "signup"
+		VerificationTicket string `json:"verificationTicket" binding:"required"`
 	}
 	var req SignUpRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -114,20 +286,12 @@ func SignUp(c *gin.Context) {
 		return
 	}
 
-	phoneUint, err := strconv.ParseUint(req.Phone, 10, 64)
+	phone, err := utils.NormalizePhone(req.Phone)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid phone number"})
 		return
 	}
-	isValid, err := dataprovider.IsPhoneVerified(uint(phoneUint))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify phone"})
-		return
-	}
-	if !isValid {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Phone not verified"})
-		return
-	}
+	req.Phone = phone
 
 	req.UserName = strings.ToLower(strings.TrimSpace(req.UserName))
 	req.Email = strings.ToLower(strings.TrimSpace(req.Email))
@@ -150,6 +314,16 @@ func SignUp(c *gin.Context) {
 		return
 	}
 
+	taken, err := dataprovider.PhoneTaken(dataprovider.DB, "admin", req.Phone, 0)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+		return
+	}
+	if taken {
+		c.JSON(http.StatusConflict, gin.H{"error": "Phone already in use by another account"})
+		return
+	}
+
 	dob, err := time.Parse("2006-01-02", req.DoB)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
@@ -166,8 +340,27 @@ func SignUp(c *gin.Context) {
 		DOB:       dob, // parsed time
 	}
 
-	if err := dataprovider.CreateAdmin(newUser); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+	// 2️⃣ Spend the phone verification ticket and create the account together
+	err = otp.Redeem("admin", req.Phone, models.OTPPurposeSignup, req.VerificationTicket, func(tx *gorm.DB) error {
+		if err := tx.Create(newUser).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return dataprovider.ErrPhoneTaken
+			}
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, otp.ErrInvalidPhone):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid phone number"})
+		case errors.Is(err, otp.ErrInvalidTicket):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Phone not verified"})
+		case errors.Is(err, dataprovider.ErrPhoneTaken):
+			c.JSON(http.StatusConflict, gin.H{"error": "Phone already in use by another account"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+		}
 		return
 	}
 
@@ -185,47 +378,39 @@ func SignUp(c *gin.Context) {
 
 // GET /user/homepage/:id
 func ClassList(c *gin.Context) {
-	userId, exists := c.Get("userId")
+	principal, exists := middlewares.GetPrincipal(c)
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
 		return
 	}
 
+	includeArchived := c.Query("includeArchived") == "true"
+
 	var classes []models.Classes
-	uid, ok := userId.(float64)
-	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid userId type"})
-		return
-	}
-	err := dataprovider.GetClassesByAdmin(uint(uid), &classes)
+	err := dataprovider.GetClassesByAdmin(principal.ID, includeArchived, &classes)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch classes"})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{
-		"admin_id":  userId,
+		"admin_id":  principal.ID,
 		"classList": classes,
 	})
 }
 
 func PersonalSummary(c *gin.Context) {
-	userID, exists := c.Get("userId")
+	principal, exists := middlewares.GetPrincipal(c)
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
-	classID, exists := c.Get("classID")
+	classID, exists := middlewares.GetClassID(c)
 	if !exists {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "classID not provided"})
 		return
 	}
-	classIDFloat, ok := classID.(uint)
-	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid classID type"})
-		return
-	}
 
-	summary, err := dataprovider.GetUserQuickSummary(uint(userID.(float64)), classIDFloat, "admin")
+	summary, err := dataprovider.GetUserQuickSummary(principal.ID, classID, "admin")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get quick summary"})
 		return
@@ -239,65 +424,38 @@ func PersonalSummary(c *gin.Context) {
 
 // POST /user/markAttendance/:id
 func MarkAttendance(c *gin.Context) {
-	ClassID := c.Param("classId")
-
-	ClassIDUint, err := strconv.ParseUint(ClassID, 10, 64)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid class ID"})
-		return
-	}
-
-	isClassExists, err := dataprovider.IfClassExists(uint(ClassIDUint))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check class"})
-		return
-	}
-
-	if !isClassExists {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Class not found"})
-		return
-	}
-
-	userId, exists := c.Get("userId")
+	principal, exists := middlewares.GetPrincipal(c)
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
-
-	isUserAdmin, err := dataprovider.IsUserAdmin(uint(userId.(float64)), uint(ClassIDUint))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check user role"})
-		return
-	}
-	if !isUserAdmin {
-		c.JSON(http.StatusForbidden, gin.H{"error": "admin privileges required"})
+	classID, exists := middlewares.GetClassID(c)
+	if !exists {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "classID not provided"})
 		return
 	}
 
-	err = dataprovider.MarkAttendanceByAdmin(uint(ClassIDUint), uint(userId.(float64)))
+	err := dataprovider.MarkAttendanceByAdmin(classID, principal.ID)
 	if err != nil {
 		if err.Error() == "already marked" {
 			c.JSON(http.StatusConflict, gin.H{"error": "Attendance already marked"})
 			return
 		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark attendance"})
+		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Attendance marked", "class_id": ClassIDUint})
+	c.JSON(http.StatusOK, gin.H{"message": "Attendance marked", "class_id": classID})
 }
 
 // GET /user/profile/:id
 func Profile(c *gin.Context) {
-	userID, exists := c.Get("userId")
+	principal, exists := middlewares.GetPrincipal(c)
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
 		return
 	}
-	uid, ok := userID.(float64)
-	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid userId type"})
-		return
-	}
-	user, err := dataprovider.GetAdminProfile(uint(uid))
+	user, err := dataprovider.GetAdminProfile(principal.ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user profile"})
 		return
@@ -307,7 +465,7 @@ func Profile(c *gin.Context) {
 
 // PATCH /user/profile/:id
 func UpdateProfile(c *gin.Context) {
-	userID, exists := c.Get("UserId")
+	principal, exists := middlewares.GetPrincipal(c)
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
@@ -331,21 +489,31 @@ func UpdateProfile(c *gin.Context) {
 		"email":      strings.ToLower(strings.TrimSpace(req.Email)),
 	}
 
-	dataprovider.UpdateAdminProfile(updateData, uint(userID.(float64)))
+	if err := dataprovider.UpdateAdminProfile(updateData, principal.ID); err != nil {
+		switch {
+		case errors.Is(err, dataprovider.ErrEmailTaken):
+			c.JSON(http.StatusConflict, gin.H{"error": "Email already in use by another account"})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
+		}
+		return
+	}
 
-	// TODO: update profile in DB using updateData
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Profile updated",
-		"user_id": userID,
+		"user_id": principal.ID,
 		"name":    req.FirstName + " " + req.LastName,
 		"email":   req.Email,
 	})
 }
 
-// POST /user/sendOTP
+// POST /admin/sendOTP
 func SendOTP(c *gin.Context) {
 	type OTPRequest struct {
-		Phone string `json:"phone"`
+		Phone   string `json:"phone" binding:"required"`
+		Purpose string `json:"purpose"`
 	}
 
 	var req OTPRequest
@@ -354,194 +522,276 @@ func SendOTP(c *gin.Context) {
 		return
 	}
 
-	otp, err := utils.GenerateOTP()
+	phone, err := utils.NormalizePhone(req.Phone)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate OTP"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid phone number"})
 		return
 	}
+	req.Phone = phone
 
-	payload := map[string]string{
-		"phone":       "+91" + req.Phone,
-		"otp":         otp,
-		"gateway_key": os.Getenv("FAZPASS_GATEWAY_KEY"),
+	// Other purposes have their own endpoints, which check the account first
+	if req.Purpose == "" {
+		req.Purpose = models.OTPPurposeSignup
 	}
-
-	payloadBytes, err := json.Marshal(payload)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to marshal payload"})
+	if req.Purpose != models.OTPPurposeSignup {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid OTP purpose"})
 		return
 	}
 
-	url := "https://api.fazpass.com/v1/otp/send"
-
-	httpReq, err := http.NewRequest("POST", url, bytes.NewBuffer(payloadBytes))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create HTTP request"})
+	if err := otp.Send(c.Request.Context(), "admin", req.Phone, req.Purpose, c.ClientIP()); err != nil {
+		var limited *otp.RateLimitError
+		switch {
+		case errors.As(err, &limited):
+			c.Header("Retry-After", strconv.Itoa(limited.RetryAfterSeconds()))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": limited.Error(), "retry_after": limited.RetryAfterSeconds()})
+		case errors.Is(err, otp.ErrInvalidPhone):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid phone number"})
+		case errors.Is(err, otp.ErrInvalidPurpose):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid OTP purpose"})
+		case errors.Is(err, otp.ErrDeliveryFailed):
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to send OTP"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store OTP"})
+		}
 		return
 	}
 
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Authorization", "Bearer "+os.Getenv("FAZPASS_MERCHANT_KEY"))
+	c.JSON(http.StatusOK, gin.H{
+		"message": "OTP sent",
+		"phone":   req.Phone,
+		"purpose": req.Purpose,
+	})
+}
 
-	// Print all content of httpReq for debugging
-	println("HTTP Request Method:", httpReq.Method)
-	println("HTTP Request URL:", httpReq.URL.String())
-	for k, v := range httpReq.Header {
-		println("Header:", k, "=", v[0])
-	}
-	if httpReq.Body != nil {
-		bodyBytes, _ := payloadBytes, error(nil)
-		if bodyBytes == nil {
-			bodyBytes, _ = io.ReadAll(httpReq.Body)
-		}
-		println("Body:", string(bodyBytes))
+// POST /admin/verifyOTP
+func VerifyOTP(c *gin.Context) {
+	type VerifyRequest struct {
+		Phone   string `json:"phone" binding:"required"`
+		OTP     string `json:"otp" binding:"required"`
+		Purpose string `json:"purpose"`
 	}
 
-	client := &http.Client{}
-	resp, err := client.Do(httpReq)
-	if err != nil {
-		// Print the error for debugging
-		println("Error sending OTP:", err.Error())
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send OTP"})
-		return
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		buf := new(bytes.Buffer)
-		buf.ReadFrom(resp.Body)
-		errorMsg := buf.String()
-		println("Failed to send OTP, status code:", resp.Status, "response:", errorMsg)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":        "Failed to send OTP",
-			"status_code":  resp.StatusCode,
-			"status":       resp.Status,
-			"response_msg": errorMsg,
-		})
+	var req VerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	phoneUint, err := strconv.ParseUint(req.Phone, 10, 64)
+	phone, err := utils.NormalizePhone(req.Phone)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid phone number"})
 		return
 	}
-	err = dataprovider.StoreOTP(uint(phoneUint), otp)
+	req.Phone = phone
+
+	// Other purposes have their own endpoints, which check the account first
+	if req.Purpose == "" {
+		req.Purpose = models.OTPPurposeSignup
+	}
+	if req.Purpose != models.OTPPurposeSignup {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid OTP purpose"})
+		return
+	}
+
+	ticket, err := otp.Verify("admin", req.Phone, req.Purpose, req.OTP)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store OTP"})
+		var limited *otp.RateLimitError
+		switch {
+		case errors.As(err, &limited):
+			c.Header("Retry-After", strconv.Itoa(limited.RetryAfterSeconds()))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": limited.Error(), "retry_after": limited.RetryAfterSeconds()})
+		case errors.Is(err, otp.ErrInvalidPhone):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid phone number"})
+		case errors.Is(err, otp.ErrInvalidPurpose):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid OTP purpose"})
+		case errors.Is(err, otp.ErrExpiredOTP):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "OTP expired"})
+		case errors.Is(err, otp.ErrInvalidOTP):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "OTP not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "OTP verification failed"})
+		}
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "OTP sent",
-		"phone":   req.Phone,
+		"message":             "OTP verified successfully",
+		"purpose":             req.Purpose,
+		"verification_ticket": ticket,
+		"expires_in":          int(otp.TicketTTL.Seconds()),
 	})
 }
 
-func VerifyOTP(c *gin.Context) {
-	type VerifyRequest struct {
-		Phone string `json:"phone"`
-		OTP   string `json:"otp"`
+// POST /admin/forgotPassword
+//
+// Sends a password_reset OTP if an account is registered to the phone. The
+// response is the same either way so it can't be used to probe for accounts.
+func ForgotPassword(c *gin.Context) {
+	type ForgotPasswordRequest struct {
+		Phone string `json:"phone" binding:"required"`
 	}
 
-	var req VerifyRequest
+	var req ForgotPasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
 		return
 	}
 
-	phoneUint, err := strconv.ParseUint(req.Phone, 10, 64)
+	phone, err := utils.NormalizePhone(req.Phone)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid phone number"})
 		return
 	}
+	req.Phone = phone
 
-	isValid, err := dataprovider.VerifyOTP(uint(phoneUint), req.OTP)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify OTP"})
+	account, err := dataprovider.GetAdminByPhone(dataprovider.DB, req.Phone)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
 		return
 	}
-	switch isValid {
-	case "Verified!":
-		err := dataprovider.MarkPhoneVerified(uint(phoneUint))
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark phone as verified"})
+
+	if account != nil {
+		// Codes only go by email to an address that has been verified
+		email := ""
+		if account.EmailVerifiedAt != nil {
+			email = account.Email
+		}
+		if err := otp.SendVia(c.Request.Context(), "admin", account.OTPChannel, req.Phone, email, models.OTPPurposePasswordReset, c.ClientIP()); err != nil {
+			var limited *otp.RateLimitError
+			switch {
+			case errors.As(err, &limited):
+				c.Header("Retry-After", strconv.Itoa(limited.RetryAfterSeconds()))
+				c.JSON(http.StatusTooManyRequests, gin.H{"error": limited.Error(), "retry_after": limited.RetryAfterSeconds()})
+			case errors.Is(err, otp.ErrInvalidPhone):
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid phone number"})
+			case errors.Is(err, otp.ErrDeliveryFailed):
+				c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to send OTP"})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store OTP"})
+			}
 			return
 		}
-		c.JSON(http.StatusOK, gin.H{"message": "OTP verified successfully"})
-		return
-	case "Expired!":
-		c.JSON(http.StatusBadRequest, gin.H{"error": "OTP expired"})
-		return
-	case "Wrong!":
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "OTP not found"})
-		return
-	case "Failed!":
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "OTP verification failed"})
-		return
-	default:
-		c.JSON(http.StatusBadRequest, gin.H{"error": isValid})
-		return
 	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "If an account uses this phone, an OTP has been sent",
+		"purpose": models.OTPPurposePasswordReset,
+	})
 }
 
-func RefreshTokenUser(c *gin.Context) {
-	// 1️⃣ Extract refresh token from cookie
-	refreshToken, err := c.Cookie("refresh_token")
-	if err != nil || refreshToken == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "refresh token missing"})
+// POST /admin/forgotPassword/verify
+//
+// Verifies the OTP sent by forgotPassword and returns the ticket that
+// resetPassword takes.
+func VerifyResetOTP(c *gin.Context) {
+	type VerifyResetOTPRequest struct {
+		Phone string `json:"phone" binding:"required"`
+		OTP   string `json:"otp" binding:"required"`
+	}
+
+	var req VerifyResetOTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
 		return
 	}
 
-	// 2️⃣ Find user with this refresh token
-	var user models.Admin
-	if err := dataprovider.DB.
-		Where("refresh_token = ?", refreshToken).
-		First(&user).Error; err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid refresh token"})
+	phone, err := utils.NormalizePhone(req.Phone)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid phone number"})
 		return
 	}
+	req.Phone = phone
 
-	// 3️⃣ Check expiry
-	if time.Now().After(*user.RefreshTokenExpiry) {
-		// clear cookie
-		c.SetCookie("refresh_token", "", -1, "/", "", true, true)
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "refresh token expired"})
+	ticket, err := otp.Verify("admin", req.Phone, models.OTPPurposePasswordReset, req.OTP)
+	if err != nil {
+		var limited *otp.RateLimitError
+		switch {
+		case errors.As(err, &limited):
+			c.Header("Retry-After", strconv.Itoa(limited.RetryAfterSeconds()))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": limited.Error(), "retry_after": limited.RetryAfterSeconds()})
+		case errors.Is(err, otp.ErrExpiredOTP):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "OTP expired"})
+		case errors.Is(err, otp.ErrInvalidOTP):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid OTP"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "OTP verification failed"})
+		}
 		return
 	}
 
-	// 4️⃣ Generate new access token
-	accessToken, err := utils.GenerateJWT(map[string]any{
-		"user_id": user.ID,
-		"role":    "admin",
+	c.JSON(http.StatusOK, gin.H{
+		"message":             "OTP verified successfully",
+		"purpose":             models.OTPPurposePasswordReset,
+		"verification_ticket": ticket,
+		"expires_in":          int(otp.TicketTTL.Seconds()),
 	})
+}
+
+// POST /admin/resetPassword
+//
+// Takes the ticket from forgotPassword/verify, sets the new
+// password and logs out every session of the account.
+func ResetPassword(c *gin.Context) {
+	type ResetPasswordRequest struct {
+		Phone              string `json:"phone" binding:"required"`
+		VerificationTicket string `json:"verificationTicket" binding:"required"`
+		NewPassword        string `json:"newPassword" binding:"required"`
+	}
+
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	phone, err := utils.NormalizePhone(req.Phone)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create access token"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid phone number"})
 		return
 	}
+	req.Phone = phone
 
-	// 5️⃣ Rotate refresh token
-	newRefreshToken, err := utils.GenerateRefreshToken()
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate refresh token"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
 		return
 	}
 
-	expiry := time.Now().Add(30 * 24 * time.Hour)
-	if err := dataprovider.UpdateUserRefreshToken(user.ID, newRefreshToken, expiry); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update refresh token"})
+	err = otp.Redeem("admin", req.Phone, models.OTPPurposePasswordReset, req.VerificationTicket, func(tx *gorm.DB) error {
+		return dataprovider.ResetAdminPassword(tx, req.Phone, string(hashedPassword))
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, otp.ErrInvalidPhone):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid phone number"})
+		case errors.Is(err, otp.ErrInvalidTicket), errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired verification ticket"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		}
 		return
 	}
 
-	c.SetCookie("refresh_token", newRefreshToken, int((30 * 24 * time.Hour).Seconds()), "/", "", true, true)
+	c.SetCookie("refresh_token", "", -1, "/", "", false, true)
+	c.JSON(http.StatusOK, gin.H{"message": "Password reset successfully"})
+}
 
-	c.JSON(http.StatusOK, gin.H{
-		"access_token": accessToken,
-	})
+// POST /admin/signIn/2fa
+func SignInTwoFactor(c *gin.Context) {
+	auth_controller.CompleteTwoFactorSignIn(c, "admin")
+}
+
+// POST /admin/signIn/2fa/setup
+func SetupTwoFactorSignIn(c *gin.Context) {
+	auth_controller.SetupTwoFactorSignIn(c, "admin")
+}
+
+func RefreshTokenUser(c *gin.Context) {
+	auth_controller.RefreshSession(c, "admin")
 }
 
 func CreateClass(c *gin.Context) {
-	adminId, exists := c.Get("userId")
+	principal, exists := middlewares.GetPrincipal(c)
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
@@ -559,14 +809,20 @@ func CreateClass(c *gin.Context) {
 		return
 	}
 
-	classCode := utils.GenerateRandomDigits(6)
+	if req.Phone != "" {
+		phone, err := utils.NormalizePhone(req.Phone)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid phone number"})
+			return
+		}
+		req.Phone = phone
+	}
 
 	class := models.Classes{
 		Name:             req.Name,
 		Email:            req.Email,
 		Phone:            req.Phone,
-		ClassCode:        classCode,
-		CreatedByAdminId: uint(adminId.(float64)),
+		CreatedByAdminId: principal.ID,
 	}
 
 	if err := dataprovider.CreateClass(&class); err != nil {
@@ -578,31 +834,12 @@ func CreateClass(c *gin.Context) {
 }
 
 func StudentsList(c *gin.Context) {
-	classId := c.Param("classId")
-	if classId == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing classId parameter"})
-		return
-	}
-	adminId, exists := c.Get("userId")
+	classID, exists := middlewares.GetClassID(c)
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
-		return
-	}
-	classIdUint, err := strconv.ParseUint(classId, 10, 64)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid classId parameter"})
-		return
-	}
-	isUserAdmin, err := dataprovider.IsUserAdmin(uint(adminId.(float64)), uint(classIdUint))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check user role"})
-		return
-	}
-	if !isUserAdmin {
-		c.JSON(http.StatusForbidden, gin.H{"error": "admin privileges required"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "classID not provided"})
 		return
 	}
-	students, err := dataprovider.GetStudentsByClassID(uint(classIdUint))
+	students, err := dataprovider.GetStudentsByClassID(classID, c.Query("includeInactive") == "true")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch students"})
 		return
@@ -611,42 +848,22 @@ func StudentsList(c *gin.Context) {
 }
 
 func LogOutAdmin(c *gin.Context) {
-	refreshToken, err := c.Cookie("refresh_token")
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing refresh token"})
-		return
-	}
-
-	// 2️⃣ Validate and revoke the refresh token
-	if err := dataprovider.RevokeAdminRefreshToken(refreshToken); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke refresh token"})
-		return
-	}
-
-	// 3️⃣ Clear the refresh token cookie
-	c.SetCookie("refresh_token", "", -1, "/", "", false, true)
-
-	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+	auth_controller.EndSession(c)
 }
 
 func Streak(c *gin.Context) {
-	userID, exists := c.Get("userId")
+	principal, exists := middlewares.GetPrincipal(c)
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
-	classID, exists := c.Get("classID")
+	classID, exists := middlewares.GetClassID(c)
 	if !exists {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "classID not provided"})
 		return
 	}
-	classIDFloat, ok := classID.(uint)
-	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid classID type"})
-		return
-	}
 
-	currentStreak, bestStreak, err := dataprovider.GetUserStreak(uint(userID.(float64)), classIDFloat, "admin")
+	currentStreak, bestStreak, err := dataprovider.GetUserStreak(principal.ID, classID, "admin")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user streak"})
 		return
@@ -656,18 +873,13 @@ func Streak(c *gin.Context) {
 }
 
 func QuickSummary(c *gin.Context) {
-	classID, exists := c.Get("classID")
+	classID, exists := middlewares.GetClassID(c)
 	if !exists {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "classID not provided"})
 		return
 	}
-	classIDFloat, ok := classID.(uint)
-	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid classID type"})
-		return
-	}
 
-	summary, err := dataprovider.GetClassSummary(classIDFloat)
+	summary, err := dataprovider.GetClassSummary(classID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get class summary"})
 		return
@@ -0,0 +1,220 @@
+package admin_controller
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	dataprovider "github.com/hyphenXY/Streak-App/internal/dataproviders"
+	middlewares "github.com/hyphenXY/Streak-App/internal/middleware"
+	"github.com/hyphenXY/Streak-App/internal/utils"
+)
+
+const (
+	maxRosterBytes = 1 << 20
+	maxRosterRows  = 1000
+)
+
+// Row outcomes decided before the database is consulted.
+const (
+	rosterInvalid   = "invalid"
+	rosterDuplicate = "duplicate"
+)
+
+// rosterColumns maps accepted CSV headers, lowercased with spaces and
+// underscores removed, to the field they fill.
+var rosterColumns = map[string]string{
+	"username":    "username",
+	"email":       "email",
+	"emailid":     "email",
+	"phone":       "phone",
+	"phonenumber": "phone",
+	"mobile":      "phone",
+}
+
+// POST /admin/classes/:classId/roster/import?dryRun=true
+//
+// Takes a CSV with a header row naming any of the username, email and phone
+// columns, either as the "file" field of a multipart form or as the raw
+// request body. Every row gets a result; with dryRun nothing is changed.
+func ImportRoster(c *gin.Context) {
+	principal, exists := middlewares.GetPrincipal(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	classID, exists := middlewares.GetClassID(c)
+	if !exists {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "classID not provided"})
+		return
+	}
+	dryRun := c.Query("dryRun") == "true"
+
+	// 1️⃣ Read the CSV
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxRosterBytes)
+	var body io.Reader = c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		file, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Upload the CSV as the \"file\" field"})
+			return
+		}
+		f, err := file.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded file"})
+			return
+		}
+		defer f.Close()
+		body = f
+	}
+
+	rows, results, err := parseRoster(body)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "CSV is larger than 1 MB"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 2️⃣ Match, enroll and invite
+	imported, err := dataprovider.ImportRoster(classID, principal.ID, rows, dryRun)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import roster"})
+		return
+	}
+
+	// 3️⃣ Report every row in file order
+	byLine := make(map[int]dataprovider.RosterResult, len(imported))
+	for _, r := range imported {
+		byLine[r.Line] = r
+	}
+	summary := map[string]int{}
+	for i, r := range results {
+		if r.Status == "" {
+			results[i] = byLine[r.Line]
+		}
+		summary[results[i].Status]++
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"class_id": classID,
+		"dry_run":  dryRun,
+		"summary":  summary,
+		"rows":     results,
+	})
+}
+
+// parseRoster reads the CSV into rows to import. It returns a result for
+// every data line, already filled in for lines that are invalid or repeat an
+// earlier one, and empty for the lines in rows.
+func parseRoster(r io.Reader) ([]dataprovider.RosterRow, []dataprovider.RosterResult, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, errors.New("CSV is empty")
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		name = strings.TrimPrefix(name, "\ufeff")
+		key := strings.NewReplacer(" ", "", "_", "", "-", "").Replace(strings.ToLower(strings.TrimSpace(name)))
+		if field, ok := rosterColumns[key]; ok {
+			if _, seen := columns[field]; !seen {
+				columns[field] = i
+			}
+		}
+	}
+	if len(columns) == 0 {
+		return nil, nil, errors.New("CSV header needs a username, email or phone column")
+	}
+
+	var rows []dataprovider.RosterRow
+	var results []dataprovider.RosterResult
+	seen := map[string]int{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		line, _ := reader.FieldPos(0)
+
+		get := func(field string) string {
+			i, ok := columns[field]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+		row := dataprovider.RosterRow{
+			Line:     line,
+			UserName: strings.ToLower(get("username")),
+			Email:    strings.ToLower(get("email")),
+			Phone:    get("phone"),
+		}
+		if row.UserName == "" && row.Email == "" && row.Phone == "" {
+			continue
+		}
+		if len(results) == maxRosterRows {
+			return nil, nil, errors.New("CSV has more than 1000 rows")
+		}
+
+		result := dataprovider.RosterResult{Line: line, UserName: row.UserName, Email: row.Email, Phone: row.Phone}
+		if row.Email != "" {
+			addr, err := mail.ParseAddress(row.Email)
+			if err != nil {
+				result.Status = rosterInvalid
+				result.Message = "invalid email"
+				results = append(results, result)
+				continue
+			}
+			// Cells like "Alice <alice@school.edu>" keep only the address
+			row.Email = strings.ToLower(addr.Address)
+			result.Email = row.Email
+		}
+		if row.Phone != "" {
+			phone, err := utils.NormalizePhone(row.Phone)
+			if err != nil {
+				result.Status = rosterInvalid
+				result.Message = "invalid phone number"
+				results = append(results, result)
+				continue
+			}
+			row.Phone = phone
+			result.Phone = phone
+		}
+
+		key := row.UserName + "|" + row.Email + "|" + row.Phone
+		if first, dup := seen[key]; dup {
+			result.Status = rosterDuplicate
+			result.Message = "same as line " + strconv.Itoa(first)
+			results = append(results, result)
+			continue
+		}
+		seen[key] = line
+
+		rows = append(rows, row)
+		results = append(results, result)
+	}
+
+	if len(results) == 0 {
+		return nil, nil, errors.New("CSV has no rows")
+	}
+	return rows, results, nil
+}
@@ -0,0 +1,104 @@
+package admin_controller
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	dataprovider "github.com/hyphenXY/Streak-App/internal/dataproviders"
+)
+
+func TestParseRoster(t *testing.T) {
+	t.Setenv("PHONE_DEFAULT_REGION", "IN")
+
+	manyRows := "email\n" + strings.Repeat("a@example.com\n", maxRosterRows+1)
+
+	tests := []struct {
+		name        string
+		csv         string
+		wantRows    []dataprovider.RosterRow
+		wantResults []dataprovider.RosterResult
+		wantErr     string
+	}{
+		{
+			name: "header aliases",
+			csv:  "\ufeffUser Name,Email_ID,Mobile,Notes\nAlice,Alice <ALICE@School.edu>,09876543210,first row\n",
+			wantRows: []dataprovider.RosterRow{
+				{Line: 2, UserName: "alice", Email: "alice@school.edu", Phone: "+919876543210"},
+			},
+			wantResults: []dataprovider.RosterResult{
+				{Line: 2, UserName: "alice", Email: "alice@school.edu", Phone: "+919876543210"},
+			},
+		},
+		{
+			name: "first of repeated columns wins",
+			csv:  "phone,mobile\n+919876543210,+919876543211\n",
+			wantRows: []dataprovider.RosterRow{
+				{Line: 2, Phone: "+919876543210"},
+			},
+			wantResults: []dataprovider.RosterResult{
+				{Line: 2, Phone: "+919876543210"},
+			},
+		},
+		{
+			name: "duplicates after normalizing",
+			csv:  "email,phone\nbob@example.com,9876543210\nBOB@example.com,+91 98765 43210\n",
+			wantRows: []dataprovider.RosterRow{
+				{Line: 2, Email: "bob@example.com", Phone: "+919876543210"},
+			},
+			wantResults: []dataprovider.RosterResult{
+				{Line: 2, Email: "bob@example.com", Phone: "+919876543210"},
+				{Line: 3, Email: "bob@example.com", Phone: "+919876543210", Status: rosterDuplicate, Message: "same as line 2"},
+			},
+		},
+		{
+			name: "invalid cells and blank lines",
+			csv:  "username,email,phone\ncarol,not-an-email,\n,,\ndave,,12345\n",
+			wantResults: []dataprovider.RosterResult{
+				{Line: 2, UserName: "carol", Email: "not-an-email", Status: rosterInvalid, Message: "invalid email"},
+				{Line: 4, UserName: "dave", Phone: "12345", Status: rosterInvalid, Message: "invalid phone number"},
+			},
+		},
+		{
+			name:    "empty",
+			csv:     "",
+			wantErr: "CSV is empty",
+		},
+		{
+			name:    "no known columns",
+			csv:     "name,notes\nerin,x\n",
+			wantErr: "CSV header needs a username, email or phone column",
+		},
+		{
+			name:    "header only",
+			csv:     "email\n",
+			wantErr: "CSV has no rows",
+		},
+		{
+			name:    "too many rows",
+			csv:     manyRows,
+			wantErr: "CSV has more than 1000 rows",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, results, err := parseRoster(strings.NewReader(tt.csv))
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(rows, tt.wantRows) {
+				t.Errorf("rows = %+v, want %+v", rows, tt.wantRows)
+			}
+			if !reflect.DeepEqual(results, tt.wantResults) {
+				t.Errorf("results = %+v, want %+v", results, tt.wantResults)
+			}
+		})
+	}
+}
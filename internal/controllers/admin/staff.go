@@ -0,0 +1,194 @@
+package admin_controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	dataprovider "github.com/hyphenXY/Streak-App/internal/dataproviders"
+	middlewares "github.com/hyphenXY/Streak-App/internal/middleware"
+	"github.com/hyphenXY/Streak-App/internal/models"
+)
+
+// GET /admin/classes/:classId/staff
+func ListStaff(c *gin.Context) {
+	classID, exists := middlewares.GetClassID(c)
+	if !exists {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "classID not provided"})
+		return
+	}
+
+	staff, err := dataprovider.ListClassStaff(classID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch staff"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"class_id": classID, "staff": staff, "permissions": models.StaffPermissions})
+}
+
+// POST /admin/classes/:classId/staff
+func InviteStaff(c *gin.Context) {
+	principal, exists := middlewares.GetPrincipal(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	classID, exists := middlewares.GetClassID(c)
+	if !exists {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "classID not provided"})
+		return
+	}
+
+	// 1️⃣ Parse JSON body
+	var req struct {
+		UserName string `json:"userName" binding:"required"`
+		Role     string `json:"role" binding:"required,oneof=co_teacher assistant"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 2️⃣ Find the admin being invited
+	admin, err := dataprovider.GetAdminByUserName(strings.ToLower(strings.TrimSpace(req.UserName)))
+	if err != nil {
+		if errors.Is(err, dataprovider.ErrAdminNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Admin not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch admin"})
+		return
+	}
+	if admin.SuspendedAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Admin account suspended"})
+		return
+	}
+
+	// 3️⃣ Record the invite
+	if err := dataprovider.InviteStaff(classID, admin.ID, req.Role, principal.ID); err != nil {
+		if errors.Is(err, dataprovider.ErrStaffExists) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Admin is already on the staff or invited"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to invite staff"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Staff invite sent",
+		"class_id": classID,
+		"admin_id": admin.ID,
+		"role":     req.Role,
+	})
+}
+
+// DELETE /admin/classes/:classId/staff/:adminId
+func RemoveStaff(c *gin.Context) {
+	classID, exists := middlewares.GetClassID(c)
+	if !exists {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "classID not provided"})
+		return
+	}
+
+	adminID, err := strconv.ParseUint(c.Param("adminId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid admin ID"})
+		return
+	}
+
+	removeStaff(c, classID, uint(adminID))
+}
+
+// POST /admin/classes/:classId/staff/leave
+func LeaveStaff(c *gin.Context) {
+	principal, exists := middlewares.GetPrincipal(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	classID, exists := middlewares.GetClassID(c)
+	if !exists {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "classID not provided"})
+		return
+	}
+
+	removeStaff(c, classID, principal.ID)
+}
+
+func removeStaff(c *gin.Context, classID uint, adminID uint) {
+	if err := dataprovider.RemoveStaff(classID, adminID); err != nil {
+		switch {
+		case errors.Is(err, dataprovider.ErrStaffNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Staff member not found"})
+		case errors.Is(err, dataprovider.ErrStaffOwnerRemoval):
+			c.JSON(http.StatusConflict, gin.H{"error": "The class owner can't be removed"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove staff member"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Staff member removed", "class_id": classID, "admin_id": adminID})
+}
+
+// GET /admin/staffInvites
+func ListStaffInvites(c *gin.Context) {
+	principal, exists := middlewares.GetPrincipal(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	invites, err := dataprovider.ListStaffInvites(principal.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch invites"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"invites": invites})
+}
+
+// POST /admin/staffInvites/:classId/accept
+func AcceptStaffInvite(c *gin.Context) {
+	answerStaffInvite(c, true)
+}
+
+// DELETE /admin/staffInvites/:classId
+func DeclineStaffInvite(c *gin.Context) {
+	answerStaffInvite(c, false)
+}
+
+func answerStaffInvite(c *gin.Context, accept bool) {
+	principal, exists := middlewares.GetPrincipal(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	classID, err := strconv.ParseUint(c.Param("classId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid class ID"})
+		return
+	}
+
+	message := "Staff invite declined"
+	if accept {
+		message = "Staff invite accepted"
+		err = dataprovider.AcceptStaffInvite(uint(classID), principal.ID)
+	} else {
+		err = dataprovider.DeclineStaffInvite(uint(classID), principal.ID)
+	}
+	if err != nil {
+		if errors.Is(err, dataprovider.ErrStaffInviteNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Invite not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update invite"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": message, "class_id": classID})
+}
@@ -0,0 +1,148 @@
+package admin_controller
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	dataprovider "github.com/hyphenXY/Streak-App/internal/dataproviders"
+	middlewares "github.com/hyphenXY/Streak-App/internal/middleware"
+	"github.com/hyphenXY/Streak-App/internal/models"
+	"github.com/hyphenXY/Streak-App/internal/utils"
+)
+
+const maxTokenLifetimeDays = 365
+
+// POST /admin/tokens
+func CreateToken(c *gin.Context) {
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// 1️⃣ Parse JSON body
+	var req struct {
+		Name          string   `json:"name" binding:"required,max=100"`
+		Scopes        []string `json:"scopes" binding:"required,min=1"`
+		ExpiresInDays *int     `json:"expiresInDays"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 2️⃣ Validate scopes and expiry
+	scopes := make([]string, 0, len(req.Scopes))
+	for _, scope := range req.Scopes {
+		if !slices.Contains(models.PATScopes, scope) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown scope: " + scope, "valid_scopes": models.PATScopes})
+			return
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresInDays != nil {
+		if *req.ExpiresInDays < 1 || *req.ExpiresInDays > maxTokenLifetimeDays {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "expiresInDays must be between 1 and 365"})
+			return
+		}
+		expiry := time.Now().AddDate(0, 0, *req.ExpiresInDays)
+		expiresAt = &expiry
+	}
+
+	// 3️⃣ Generate and store the token
+	token, err := utils.GeneratePersonalAccessToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	pat := models.PersonalAccessToken{
+		AdminID:   principal.ID,
+		Name:      strings.TrimSpace(req.Name),
+		Prefix:    token[:len(utils.PersonalAccessTokenPrefix)+4],
+		TokenHash: utils.HashToken(token),
+		Scopes:    strings.Join(scopes, ","),
+		ExpiresAt: expiresAt,
+	}
+	if err := dataprovider.CreatePersonalAccessToken(&pat); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create token"})
+		return
+	}
+
+	// 4️⃣ Respond; the token is only ever shown here
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Token created. Copy it now, it won't be shown again.",
+		"token":   token,
+		"details": tokenResponse(pat),
+	})
+}
+
+// GET /admin/tokens
+func ListTokens(c *gin.Context) {
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	tokens, err := dataprovider.ListPersonalAccessTokens(principal.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tokens"})
+		return
+	}
+
+	list := make([]gin.H, 0, len(tokens))
+	for _, t := range tokens {
+		list = append(list, tokenResponse(t))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tokens": list})
+}
+
+// DELETE /admin/tokens/:tokenId
+func RevokeToken(c *gin.Context) {
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	tokenID, err := strconv.ParseUint(c.Param("tokenId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid token ID"})
+		return
+	}
+
+	if err := dataprovider.RevokePersonalAccessToken(uint(tokenID), principal.ID); err != nil {
+		if errors.Is(err, dataprovider.ErrPersonalAccessTokenNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Token not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Token revoked", "token_id": tokenID})
+}
+
+func tokenResponse(t models.PersonalAccessToken) gin.H {
+	return gin.H{
+		"id":           t.ID,
+		"name":         t.Name,
+		"prefix":       t.Prefix,
+		"scopes":       strings.Split(t.Scopes, ","),
+		"expires_at":   t.ExpiresAt,
+		"expired":      t.ExpiresAt != nil && t.ExpiresAt.Before(time.Now()),
+		"last_used_at": t.LastUsedAt,
+		"created_at":   t.CreatedAt,
+	}
+}
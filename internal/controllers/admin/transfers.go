@@ -0,0 +1,188 @@
+package admin_controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	dataprovider "github.com/hyphenXY/Streak-App/internal/dataproviders"
+	middlewares "github.com/hyphenXY/Streak-App/internal/middleware"
+)
+
+// POST /admin/classes/:classId/transfer
+func ProposeClassTransfer(c *gin.Context) {
+	principal, exists := middlewares.GetPrincipal(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	classID, exists := middlewares.GetClassID(c)
+	if !exists {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "classID not provided"})
+		return
+	}
+
+	// 1️⃣ Parse JSON body
+	var req struct {
+		UserName string `json:"userName" binding:"required"`
+		Note     string `json:"note" binding:"max=255"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 2️⃣ Find the receiving admin
+	admin, err := dataprovider.GetAdminByUserName(strings.ToLower(strings.TrimSpace(req.UserName)))
+	if err != nil {
+		if errors.Is(err, dataprovider.ErrAdminNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Admin not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch admin"})
+		return
+	}
+	if admin.SuspendedAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Admin account suspended"})
+		return
+	}
+
+	// 3️⃣ Record the proposal
+	transfer, err := dataprovider.ProposeClassTransfer(classID, admin.ID, principal.ID, "admin", strings.TrimSpace(req.Note))
+	if err != nil {
+		classTransferError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":     "Transfer proposed. It takes effect once the receiving admin accepts it.",
+		"transfer_id": transfer.ID,
+		"class_id":    classID,
+		"to_admin_id": admin.ID,
+	})
+}
+
+// DELETE /admin/classes/:classId/transfer
+func CancelClassTransfer(c *gin.Context) {
+	principal, exists := middlewares.GetPrincipal(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	classID, exists := middlewares.GetClassID(c)
+	if !exists {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "classID not provided"})
+		return
+	}
+
+	if err := dataprovider.CancelClassTransfer(classID, principal.ID, "admin"); err != nil {
+		classTransferError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Transfer cancelled", "class_id": classID})
+}
+
+// GET /admin/classTransfers
+func ListClassTransfers(c *gin.Context) {
+	principal, exists := middlewares.GetPrincipal(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	transfers, err := dataprovider.ListIncomingClassTransfers(principal.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transfers"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"transfers": transfers})
+}
+
+// POST /admin/classTransfers/:transferId/accept
+func AcceptClassTransfer(c *gin.Context) {
+	principal, exists := middlewares.GetPrincipal(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	transferID, err := strconv.ParseUint(c.Param("transferId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transfer ID"})
+		return
+	}
+
+	transfer, err := dataprovider.AcceptClassTransfer(uint(transferID), principal.ID)
+	if err != nil {
+		classTransferError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "You now own this class",
+		"class_id":      transfer.ClassID,
+		"from_admin_id": transfer.FromAdminID,
+	})
+}
+
+// POST /admin/classTransfers/:transferId/decline
+func DeclineClassTransfer(c *gin.Context) {
+	principal, exists := middlewares.GetPrincipal(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	transferID, err := strconv.ParseUint(c.Param("transferId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transfer ID"})
+		return
+	}
+
+	if err := dataprovider.DeclineClassTransfer(uint(transferID), principal.ID); err != nil {
+		classTransferError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Transfer declined", "transfer_id": transferID})
+}
+
+// GET /admin/classes/:classId/events
+func ClassEvents(c *gin.Context) {
+	classID, exists := middlewares.GetClassID(c)
+	if !exists {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "classID not provided"})
+		return
+	}
+
+	events, err := dataprovider.ListClassEvents(classID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch class events"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"class_id": classID, "events": events})
+}
+
+// classTransferError writes the response for an error from the class
+// transfer dataprovider functions.
+func classTransferError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, dataprovider.ErrClassNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Class not found"})
+	case errors.Is(err, dataprovider.ErrClassTransferNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "No pending transfer found"})
+	case errors.Is(err, dataprovider.ErrClassTransferPending):
+		c.JSON(http.StatusConflict, gin.H{"error": "This class already has a pending transfer"})
+	case errors.Is(err, dataprovider.ErrClassTransferToOwner):
+		c.JSON(http.StatusConflict, gin.H{"error": "That admin already owns this class"})
+	case errors.Is(err, dataprovider.ErrClassTransferStale):
+		c.JSON(http.StatusConflict, gin.H{"error": "The class changed owner since this transfer was proposed"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update class transfer"})
+	}
+}
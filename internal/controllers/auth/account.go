@@ -0,0 +1,196 @@
+package auth_controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	dataprovider "github.com/hyphenXY/Streak-App/internal/dataproviders"
+	middlewares "github.com/hyphenXY/Streak-App/internal/middleware"
+	"github.com/hyphenXY/Streak-App/internal/models"
+	"github.com/hyphenXY/Streak-App/internal/otp"
+	"github.com/hyphenXY/Streak-App/internal/utils"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// POST /<role>/changePassword
+//
+// Needs the current password. Every other session of the account is signed
+// out; the one making the request stays signed in.
+func ChangePassword(c *gin.Context) {
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	type ChangePasswordRequest struct {
+		CurrentPassword string `json:"currentPassword" binding:"required"`
+		NewPassword     string `json:"newPassword" binding:"required"`
+	}
+
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	// Guessing the current password counts against the sign-in lockout
+	if !CheckSignInAllowed(c, principal.Role, SubjectSignInKey(principal.ID), principal.ID) {
+		return
+	}
+
+	currentHash, err := dataprovider.GetPasswordHash(principal.ID, principal.Role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch account"})
+		return
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(currentHash), []byte(req.CurrentPassword)); err != nil {
+		RecordSignInFailure(c, principal.Role, SubjectSignInKey(principal.ID), principal.ID, "wrong current password on password change")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Current password is incorrect"})
+		return
+	}
+	SignInSucceeded(c)
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		return
+	}
+
+	revoked, err := dataprovider.ChangePassword(principal.ID, principal.Role, string(hashedPassword), principal.SessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change password"})
+		return
+	}
+
+	recordAuthEvent(c, models.AuthEventPasswordChange, principal.Role, principal.ID, sessionRef(principal), "")
+	c.JSON(http.StatusOK, gin.H{
+		"message":          "Password changed",
+		"revoked_sessions": revoked,
+	})
+}
+
+// POST /<role>/changePhone/sendOTP
+//
+// Sends a phone_change OTP to the new number, unless another account
+// already uses it.
+func SendChangePhoneOTP(c *gin.Context) {
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	type SendChangePhoneOTPRequest struct {
+		Phone string `json:"phone" binding:"required"`
+	}
+
+	var req SendChangePhoneOTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	phone, err := utils.NormalizePhone(req.Phone)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid phone number"})
+		return
+	}
+
+	taken, err := dataprovider.PhoneTaken(dataprovider.DB, principal.Role, phone, principal.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+		return
+	}
+	if taken {
+		c.JSON(http.StatusConflict, gin.H{"error": "Phone already in use by another account"})
+		return
+	}
+
+	if err := otp.Send(c.Request.Context(), principal.Role, phone, models.OTPPurposePhoneChange, c.ClientIP()); err != nil {
+		var limited *otp.RateLimitError
+		switch {
+		case errors.As(err, &limited):
+			c.Header("Retry-After", strconv.Itoa(limited.RetryAfterSeconds()))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": limited.Error(), "retry_after": limited.RetryAfterSeconds()})
+		case errors.Is(err, otp.ErrDeliveryFailed):
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to send OTP"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store OTP"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "OTP sent",
+		"phone":   phone,
+		"purpose": models.OTPPurposePhoneChange,
+	})
+}
+
+// POST /<role>/changePhone
+//
+// Verifies the OTP sent to the new number by changePhone/sendOTP and moves
+// the account to it.
+func ChangePhone(c *gin.Context) {
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	type ChangePhoneRequest struct {
+		Phone string `json:"phone" binding:"required"`
+		OTP   string `json:"otp" binding:"required"`
+	}
+
+	var req ChangePhoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	phone, err := utils.NormalizePhone(req.Phone)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid phone number"})
+		return
+	}
+
+	ticket, err := otp.Verify(principal.Role, phone, models.OTPPurposePhoneChange, req.OTP)
+	if err == nil {
+		err = otp.Redeem(principal.Role, phone, models.OTPPurposePhoneChange, ticket, func(tx *gorm.DB) error {
+			return dataprovider.ChangePhone(tx, principal.ID, principal.Role, phone)
+		})
+	}
+	if err != nil {
+		var limited *otp.RateLimitError
+		switch {
+		case errors.As(err, &limited):
+			c.Header("Retry-After", strconv.Itoa(limited.RetryAfterSeconds()))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": limited.Error(), "retry_after": limited.RetryAfterSeconds()})
+		case errors.Is(err, dataprovider.ErrPhoneTaken):
+			c.JSON(http.StatusConflict, gin.H{"error": "Phone already in use by another account"})
+		case errors.Is(err, otp.ErrExpiredOTP):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "OTP expired"})
+		case errors.Is(err, otp.ErrInvalidOTP), errors.Is(err, otp.ErrInvalidTicket):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid OTP"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change phone"})
+		}
+		return
+	}
+
+	recordAuthEvent(c, models.AuthEventPhoneChange, principal.Role, principal.ID, sessionRef(principal), "")
+	c.JSON(http.StatusOK, gin.H{"message": "Phone changed", "phone": phone})
+}
+
+// sessionRef returns the principal's session for an AuthEvent, if it has one.
+func sessionRef(principal middlewares.Principal) *uint {
+	if principal.SessionID == 0 {
+		return nil
+	}
+	return &principal.SessionID
+}
@@ -0,0 +1,70 @@
+package auth_controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	dataprovider "github.com/hyphenXY/Streak-App/internal/dataproviders"
+	middlewares "github.com/hyphenXY/Streak-App/internal/middleware"
+	"github.com/hyphenXY/Streak-App/internal/models"
+)
+
+// GET /<role>/authEvents?type=sign_in_failure&page=1&pageSize=20
+//
+// The caller's own sign-ins and other security events, newest first.
+func ListAuthEvents(c *gin.Context) {
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page"})
+		return
+	}
+	pageSize, err := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	if err != nil || pageSize < 1 || pageSize > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "pageSize must be between 1 and 100"})
+		return
+	}
+
+	filter := dataprovider.AuthEventFilter{
+		Role:      principal.Role,
+		SubjectID: &principal.ID,
+		Type:      c.Query("type"),
+	}
+	events, total, err := dataprovider.ListAuthEvents(filter, page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch auth events"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"events":   AuthEventList(events),
+		"page":     page,
+		"pageSize": pageSize,
+		"total":    total,
+	})
+}
+
+// AuthEventList renders auth events for a JSON response.
+func AuthEventList(events []models.AuthEvent) []gin.H {
+	list := make([]gin.H, 0, len(events))
+	for _, e := range events {
+		list = append(list, gin.H{
+			"id":         e.ID,
+			"type":       e.Type,
+			"role":       e.Role,
+			"subject_id": e.SubjectID,
+			"session_id": e.SessionID,
+			"ip":         e.IP,
+			"user_agent": e.UserAgent,
+			"details":    e.Details,
+			"created_at": e.CreatedAt,
+		})
+	}
+	return list
+}
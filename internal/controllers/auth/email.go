@@ -0,0 +1,172 @@
+package auth_controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	dataprovider "github.com/hyphenXY/Streak-App/internal/dataproviders"
+	"github.com/hyphenXY/Streak-App/internal/mail"
+	middlewares "github.com/hyphenXY/Streak-App/internal/middleware"
+	"github.com/hyphenXY/Streak-App/internal/models"
+	"github.com/hyphenXY/Streak-App/internal/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	emailCodeTTL           = 30 * time.Minute
+	emailCodeCooldown      = time.Minute
+	maxEmailVerifyAttempts = 5
+)
+
+// POST /<role>/email/sendVerification
+//
+// Mails a code that proves the caller owns the email on their account.
+func SendEmailVerification(c *gin.Context) {
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	contact, err := dataprovider.GetAccountContact(principal.ID, principal.Role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch account"})
+		return
+	}
+	if contact.Email == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No email on this account"})
+		return
+	}
+	if contact.EmailVerifiedAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Email already verified"})
+		return
+	}
+
+	latest, err := dataprovider.GetLatestEmailVerification(principal.ID, principal.Role)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+		return
+	}
+	if latest != nil {
+		if wait := time.Until(latest.CreatedAt.Add(emailCodeCooldown)); wait > 0 {
+			secs := max(int((wait+time.Second-1)/time.Second), 1)
+			c.Header("Retry-After", strconv.Itoa(secs))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "please wait before requesting another code", "retry_after": secs})
+			return
+		}
+	}
+
+	code, err := utils.GenerateOTP()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate code"})
+		return
+	}
+
+	err = dataprovider.CreateEmailVerification(&models.EmailVerification{
+		SubjectID: principal.ID,
+		Role:      principal.Role,
+		Email:     contact.Email,
+		CodeHash:  utils.HashToken(code),
+		Expiry:    time.Now().Add(emailCodeTTL),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store code"})
+		return
+	}
+
+	body := fmt.Sprintf("Your Streak email verification code is %s. It expires in %d minutes.\n\nIf you didn't ask for it, ignore this email.",
+		code, int(emailCodeTTL.Minutes()))
+	if err := mail.Send(c.Request.Context(), contact.Email, "Verify your email", body); err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to send email"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Verification code sent",
+		"email":      contact.Email,
+		"expires_in": int(emailCodeTTL.Seconds()),
+	})
+}
+
+// POST /<role>/email/verify
+func VerifyEmail(c *gin.Context) {
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	type VerifyEmailRequest struct {
+		Code string `json:"code" binding:"required"`
+	}
+
+	var req VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	err := dataprovider.ConsumeEmailVerification(principal.ID, principal.Role, utils.HashToken(req.Code), maxEmailVerifyAttempts)
+	if err != nil {
+		switch {
+		case errors.Is(err, dataprovider.ErrEmailVerificationNotFound), errors.Is(err, dataprovider.ErrEmailVerificationMismatch):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid code"})
+		case errors.Is(err, dataprovider.ErrEmailVerificationExpired):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Code expired"})
+		case errors.Is(err, dataprovider.ErrEmailVerificationLocked):
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "too many wrong attempts, request a new code"})
+		case errors.Is(err, dataprovider.ErrEmailChanged):
+			c.JSON(http.StatusConflict, gin.H{"error": "Email changed since the code was sent, request a new code"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Email verification failed"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email verified"})
+}
+
+// PUT /<role>/otpChannel
+//
+// Chooses whether login and password reset codes arrive by SMS or email.
+// Email needs a verified address.
+func SetOTPChannel(c *gin.Context) {
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	type OTPChannelRequest struct {
+		Channel string `json:"channel" binding:"required,oneof=sms email"`
+	}
+
+	var req OTPChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "channel must be sms or email"})
+		return
+	}
+
+	if req.Channel == models.OTPChannelEmail {
+		contact, err := dataprovider.GetAccountContact(principal.ID, principal.Role)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch account"})
+			return
+		}
+		if contact.EmailVerifiedAt == nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "Verify your email first"})
+			return
+		}
+	}
+
+	if err := dataprovider.SetOTPChannel(principal.ID, principal.Role, req.Channel); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update OTP channel"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "OTP channel updated", "channel": req.Channel})
+}
@@ -0,0 +1,255 @@
+// Package auth_controller holds the session handling shared by the user,
+// admin and root controllers.
+package auth_controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	dataprovider "github.com/hyphenXY/Streak-App/internal/dataproviders"
+	middlewares "github.com/hyphenXY/Streak-App/internal/middleware"
+	"github.com/hyphenXY/Streak-App/internal/models"
+	"github.com/hyphenXY/Streak-App/internal/utils"
+)
+
+const refreshTokenTTL = 30 * 24 * time.Hour // 30 days
+
+// StartSession opens a new session for the signed-in subject, sets its
+// refresh token cookie and returns a fresh access token. The sign-in is
+// recorded in the auth events trail.
+func StartSession(c *gin.Context, subjectID uint, role string, deviceName string) (string, error) {
+	refreshToken, err := utils.GenerateRefreshToken()
+	if err != nil {
+		return "", err
+	}
+
+	session := &models.Session{
+		SubjectID:  subjectID,
+		Role:       role,
+		Expiry:     time.Now().Add(refreshTokenTTL),
+		DeviceName: truncate(deviceName, 100),
+		IP:         c.ClientIP(),
+		UserAgent:  truncate(c.Request.UserAgent(), 255),
+		LastUsedAt: time.Now(),
+	}
+	if err := dataprovider.CreateSession(session, utils.HashToken(refreshToken)); err != nil {
+		return "", err
+	}
+
+	accessToken, err := utils.GenerateAccessToken(subjectID, role, session.ID)
+	if err != nil {
+		return "", err
+	}
+
+	recordAuthEvent(c, models.AuthEventSignInSuccess, role, subjectID, &session.ID, session.DeviceName)
+	setRefreshCookie(c, refreshToken, int(refreshTokenTTL.Seconds()))
+	return accessToken, nil
+}
+
+// RefreshSession rotates the refresh token cookie of a session belonging to
+// role and returns a new access token. Presenting a token that was already
+// rotated means it leaked, so the whole session is revoked.
+func RefreshSession(c *gin.Context, role string) {
+	// 1️⃣ Extract refresh token from cookie
+	refreshToken, err := c.Cookie("refresh_token")
+	if err != nil || refreshToken == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "refresh token missing"})
+		return
+	}
+
+	// 2️⃣ Find the token and the session it belongs to
+	token, session, err := dataprovider.GetRefreshToken(utils.HashToken(refreshToken))
+	if err != nil || session.Role != role || session.RevokedAt != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid refresh token"})
+		return
+	}
+
+	ip := c.ClientIP()
+	userAgent := truncate(c.Request.UserAgent(), 255)
+
+	if token.RotatedAt != nil {
+		revokeReusedSession(c, session, ip, userAgent)
+		return
+	}
+
+	// 3️⃣ Check expiry
+	if time.Now().After(token.Expiry) {
+		setRefreshCookie(c, "", -1)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "refresh token expired"})
+		return
+	}
+
+	if role == "admin" {
+		suspended, err := dataprovider.IsAdminSuspended(session.SubjectID)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid refresh token"})
+			return
+		}
+		if suspended {
+			setRefreshCookie(c, "", -1)
+			c.JSON(http.StatusForbidden, gin.H{"error": "admin account suspended"})
+			return
+		}
+	}
+
+	// 4️⃣ Rotate refresh token
+	newRefreshToken, err := utils.GenerateRefreshToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate refresh token"})
+		return
+	}
+
+	expiry := time.Now().Add(refreshTokenTTL)
+	if err := dataprovider.RotateRefreshToken(token, utils.HashToken(newRefreshToken), expiry, ip, userAgent); err != nil {
+		if errors.Is(err, dataprovider.ErrRefreshTokenReused) {
+			revokeReusedSession(c, session, ip, userAgent)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update refresh token"})
+		return
+	}
+
+	// 5️⃣ Generate new access token
+	accessToken, err := utils.GenerateAccessToken(session.SubjectID, role, session.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create access token"})
+		return
+	}
+
+	setRefreshCookie(c, newRefreshToken, int(refreshTokenTTL.Seconds()))
+
+	c.JSON(http.StatusOK, gin.H{
+		"access_token": accessToken,
+	})
+}
+
+func revokeReusedSession(c *gin.Context, session *models.Session, ip string, userAgent string) {
+	if err := dataprovider.RevokeSessionForReuse(session, ip, userAgent); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke session"})
+		return
+	}
+	setRefreshCookie(c, "", -1)
+	c.JSON(http.StatusUnauthorized, gin.H{"error": "refresh token reused; session revoked"})
+}
+
+// EndSession revokes the session behind the refresh token cookie.
+func EndSession(c *gin.Context) {
+	refreshToken, err := c.Cookie("refresh_token")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing refresh token"})
+		return
+	}
+
+	if err := dataprovider.RevokeSessionByRefreshToken(utils.HashToken(refreshToken)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke refresh token"})
+		return
+	}
+
+	setRefreshCookie(c, "", -1)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+}
+
+// GET /<role>/sessions
+func ListSessions(c *gin.Context) {
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	sessions, err := dataprovider.ListActiveSessions(principal.ID, principal.Role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sessions"})
+		return
+	}
+
+	list := make([]gin.H, 0, len(sessions))
+	for _, s := range sessions {
+		list = append(list, gin.H{
+			"id":           s.ID,
+			"device_name":  s.DeviceName,
+			"ip":           s.IP,
+			"user_agent":   s.UserAgent,
+			"created_at":   s.CreatedAt,
+			"last_used_at": s.LastUsedAt,
+			"current":      s.ID == principal.SessionID,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sessions": list})
+}
+
+// DELETE /<role>/sessions/:sessionId
+func RevokeSession(c *gin.Context) {
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	sessionID, err := strconv.ParseUint(c.Param("sessionId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return
+	}
+
+	if err := dataprovider.RevokeSession(uint(sessionID), principal.ID, principal.Role); err != nil {
+		if errors.Is(err, dataprovider.ErrSessionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked", "session_id": sessionID})
+}
+
+// POST /<role>/sessions/revokeOthers
+func RevokeOtherSessions(c *gin.Context) {
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok || principal.SessionID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	revoked, err := dataprovider.RevokeOtherSessions(principal.ID, principal.Role, principal.SessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Other sessions revoked", "revoked": revoked})
+}
+
+func setRefreshCookie(c *gin.Context, value string, maxAge int) {
+	secure := gin.Mode() == gin.ReleaseMode
+	c.SetCookie(
+		"refresh_token",
+		value,
+		maxAge, // expiry in seconds
+		"/",
+		"",     // domain (empty = current domain)
+		secure, // secure (true = HTTPS only)
+		true,   // httpOnly
+	)
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
+
+// GET /.well-known/jwks.json
+//
+// Lets other services verify Streak access tokens without sharing a secret.
+func JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, utils.JWKS())
+}
@@ -0,0 +1,200 @@
+package auth_controller
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	dataprovider "github.com/hyphenXY/Streak-App/internal/dataproviders"
+	"github.com/hyphenXY/Streak-App/internal/models"
+	"github.com/hyphenXY/Streak-App/internal/utils"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// InvalidCredentials is the one error every failed sign-in gets, so callers
+// can't tell an unknown username from a wrong password.
+const InvalidCredentials = "Invalid username or password"
+
+const (
+	// SignInWindow is how far back failed sign-ins are counted.
+	SignInWindow = 15 * time.Minute
+	// FreeSignInFailures failures are allowed before delays kick in. Each
+	// failure after that doubles the wait, up to MaxSignInDelay.
+	FreeSignInFailures = 3
+	MaxSignInDelay     = 5 * time.Minute
+	// MaxSignInFailures failures lock the account for SignInLockout.
+	MaxSignInFailures = 10
+	SignInLockout     = 15 * time.Minute
+	// MaxSignInFailuresPerIP failures from one address within SignInWindow
+	// block it until the oldest drops out of the window.
+	MaxSignInFailuresPerIP = 30
+)
+
+// dummyPasswordHash is compared against when the username is unknown, so a
+// miss takes as long as a wrong password.
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("streak-dummy-password"), bcrypt.DefaultCost)
+
+// UserNameSignInKey is the lockout key of a password sign-in. It is the same
+// whether or not an account has the username, so the lockout can't be used
+// to probe for accounts, and only its hash is stored.
+func UserNameSignInKey(userName string) string {
+	return utils.HashToken("username:" + NormalizeUserName(userName))
+}
+
+// PhoneSignInKey is the lockout key of an OTP sign-in to the E.164 phone.
+func PhoneSignInKey(phone string) string {
+	return utils.HashToken("phone:" + phone)
+}
+
+// SubjectSignInKey is the lockout key of password and second factor checks
+// made for an account that is already known, like changing the password.
+func SubjectSignInKey(subjectID uint) string {
+	return utils.HashToken("subject:" + strconv.FormatUint(uint64(subjectID), 10))
+}
+
+// NormalizeUserName is how usernames are stored and looked up.
+func NormalizeUserName(userName string) string {
+	return strings.ToLower(strings.TrimSpace(userName))
+}
+
+// DummyPasswordCheck spends the time of a bcrypt compare, for sign-ins to a
+// username that doesn't exist.
+func DummyPasswordCheck(password string) {
+	_ = bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+}
+
+// signInAttemptKey holds the ID of the attempt CheckSignInAllowed recorded.
+const signInAttemptKey = "signInAttempt"
+
+// CheckSignInAllowed enforces the per-key and per-IP sign-in lockout before
+// any password or code is checked. key comes from UserNameSignInKey and
+// friends; subjectID is the account behind it, or 0 if there is none, and
+// only decides which successful sign-in resets the count. If the attempt is
+// refused it answers 429 with Retry-After and returns false.
+//
+// An allowed attempt is counted as a failure straight away, under a lock on
+// the key, so a burst of parallel guesses can't all see the same count. The
+// handler must call SignInSucceeded once the credentials check out, or
+// RejectSignIn/RecordSignInFailure when they don't.
+func CheckSignInAllowed(c *gin.Context, role string, key string, subjectID uint) bool {
+	now := time.Now()
+	attempt := &models.AuthEvent{
+		Type:       models.AuthEventSignInFailure,
+		Role:       role,
+		SubjectID:  subjectID,
+		LockoutKey: key,
+		IP:         c.ClientIP(),
+		UserAgent:  truncate(c.Request.UserAgent(), 255),
+		Details:    "sign-in attempt did not finish",
+	}
+
+	var retryAfter time.Duration
+	allowed, err := dataprovider.BeginSignInAttempt(attempt, now.Add(-SignInWindow), func(stats dataprovider.SignInFailureStats) bool {
+		if stats.LastAccountFailure != nil && stats.AccountFailures >= FreeSignInFailures {
+			wait := SignInLockout
+			if stats.AccountFailures < MaxSignInFailures {
+				wait = min(time.Second<<(stats.AccountFailures-FreeSignInFailures), MaxSignInDelay)
+			}
+			retryAfter = stats.LastAccountFailure.Add(wait).Sub(now)
+		}
+		if stats.OldestIPFailure != nil && stats.IPFailures >= MaxSignInFailuresPerIP {
+			retryAfter = max(retryAfter, stats.OldestIPFailure.Add(SignInWindow).Sub(now))
+		}
+		return retryAfter <= 0
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check sign-in attempts"})
+		return false
+	}
+	if allowed {
+		c.Set(signInAttemptKey, attempt.ID)
+		return true
+	}
+
+	recordSignInEvent(c, models.AuthEventSignInBlocked, role, key, subjectID, "too many failed sign-ins")
+
+	secs := max(int((retryAfter+time.Second-1)/time.Second), 1)
+	c.Header("Retry-After", strconv.Itoa(secs))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":       "Too many failed sign-in attempts, try again later",
+		"retry_after": secs,
+	})
+	return false
+}
+
+// SignInSucceeded takes back the failure CheckSignInAllowed counted, once the
+// password or code turned out to be right.
+func SignInSucceeded(c *gin.Context) {
+	eventID, ok := takeSignInAttempt(c)
+	if !ok {
+		return
+	}
+	if err := dataprovider.DeleteSignInAttempt(eventID); err != nil {
+		log.Printf("❌ clearing sign-in attempt %d failed: %v", eventID, err)
+	}
+}
+
+// RejectSignIn records a failed sign-in and answers with InvalidCredentials.
+// details is kept in the audit trail only, never sent to the client.
+func RejectSignIn(c *gin.Context, role string, key string, subjectID uint, details string) {
+	RecordSignInFailure(c, role, key, subjectID, details)
+	c.JSON(http.StatusUnauthorized, gin.H{"error": InvalidCredentials})
+}
+
+// RecordSignInFailure records a failed sign-in against the lockout key, for
+// callers that answer the client themselves. The failure CheckSignInAllowed
+// already counted is reused if there is one.
+func RecordSignInFailure(c *gin.Context, role string, key string, subjectID uint, details string) {
+	if eventID, ok := takeSignInAttempt(c); ok {
+		if err := dataprovider.UpdateSignInAttempt(eventID, truncate(details, 255)); err != nil {
+			log.Printf("❌ updating sign-in attempt %d failed: %v", eventID, err)
+		}
+		return
+	}
+	recordSignInEvent(c, models.AuthEventSignInFailure, role, key, subjectID, details)
+}
+
+// takeSignInAttempt returns the attempt CheckSignInAllowed recorded for this
+// request and forgets it, so it is only resolved once.
+func takeSignInAttempt(c *gin.Context) (uint, bool) {
+	id, ok := c.Get(signInAttemptKey)
+	if !ok {
+		return 0, false
+	}
+	c.Set(signInAttemptKey, nil)
+	eventID, ok := id.(uint)
+	return eventID, ok
+}
+
+func recordSignInEvent(c *gin.Context, eventType string, role string, key string, subjectID uint, details string) {
+	err := dataprovider.CreateAuthEvent(&models.AuthEvent{
+		Type:       eventType,
+		Role:       role,
+		SubjectID:  subjectID,
+		LockoutKey: key,
+		IP:         c.ClientIP(),
+		UserAgent:  truncate(c.Request.UserAgent(), 255),
+		Details:    truncate(details, 255),
+	})
+	if err != nil {
+		log.Printf("❌ recording %s event failed: %v", eventType, err)
+	}
+}
+
+func recordAuthEvent(c *gin.Context, eventType string, role string, subjectID uint, sessionID *uint, details string) {
+	err := dataprovider.CreateAuthEvent(&models.AuthEvent{
+		Type:      eventType,
+		Role:      role,
+		SubjectID: subjectID,
+		SessionID: sessionID,
+		IP:        c.ClientIP(),
+		UserAgent: truncate(c.Request.UserAgent(), 255),
+		Details:   truncate(details, 255),
+	})
+	if err != nil {
+		log.Printf("❌ recording %s event failed: %v", eventType, err)
+	}
+}
@@ -0,0 +1,269 @@
+package auth_controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	middlewares "github.com/hyphenXY/Streak-App/internal/middleware"
+	"github.com/hyphenXY/Streak-App/internal/twofactor"
+)
+
+// ChallengeTwoFactor is called by SignIn once the password has been checked.
+// If the subject uses two-factor authentication, or has to, it answers with
+// a challenge token instead of a session and returns true.
+func ChallengeTwoFactor(c *gin.Context, subjectID uint, role string, deviceName string) bool {
+	enabled, err := twofactor.Enabled(subjectID, role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check two-factor status"})
+		return true
+	}
+	required, err := twofactor.Required(role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check two-factor status"})
+		return true
+	}
+	if !enabled && !required {
+		return false
+	}
+
+	token, err := twofactor.NewChallenge(subjectID, role, truncate(deviceName, 100))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start two-factor challenge"})
+		return true
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":             "Two-factor authentication required",
+		"role":                role,
+		"two_factor_required": true,
+		// Accounts made to use 2FA must call signIn/2fa/setup first.
+		"setup_required":  !enabled,
+		"challenge_token": token,
+		"expires_in":      int(twofactor.ChallengeTTL.Seconds()),
+	})
+	return true
+}
+
+// CompleteTwoFactorSignIn trades a challenge token and a TOTP or recovery
+// code for a session.
+func CompleteTwoFactorSignIn(c *gin.Context, role string) {
+	type TwoFactorSignInRequest struct {
+		ChallengeToken string `json:"challengeToken" binding:"required"`
+		Code           string `json:"code" binding:"required"`
+	}
+
+	var req TwoFactorSignInRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	challenge, recoveryCodes, err := twofactor.AnswerChallenge(req.ChallengeToken, role, req.Code)
+	if err != nil {
+		if challenge != nil && errors.Is(err, twofactor.ErrInvalidCode) {
+			RecordSignInFailure(c, role, SubjectSignInKey(challenge.SubjectID), challenge.SubjectID, "invalid two-factor code")
+		}
+		twoFactorError(c, err)
+		return
+	}
+
+	accessToken, err := StartSession(c, challenge.SubjectID, role, challenge.DeviceName)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start session"})
+		return
+	}
+
+	resp := gin.H{
+		"message":      "Sign in successful",
+		"role":         role,
+		"access_token": accessToken,
+		"user_id":      challenge.SubjectID,
+	}
+	if recoveryCodes != nil {
+		resp["recovery_codes"] = recoveryCodes
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// SetupTwoFactorSignIn starts TOTP setup for an account that has to enroll
+// before its sign-in can finish.
+func SetupTwoFactorSignIn(c *gin.Context, role string) {
+	type SetupRequest struct {
+		ChallengeToken string `json:"challengeToken" binding:"required"`
+	}
+
+	var req SetupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	secret, uri, err := twofactor.SetupForChallenge(req.ChallengeToken, role)
+	if err != nil {
+		twoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"secret":           secret,
+		"provisioning_uri": uri,
+	})
+}
+
+// GET /<role>/2fa
+func TwoFactorStatus(c *gin.Context) {
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	enabled, err := twofactor.Enabled(principal.ID, principal.Role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check two-factor status"})
+		return
+	}
+	required, err := twofactor.Required(principal.Role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check two-factor status"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"enabled": enabled, "required": required})
+}
+
+// POST /<role>/2fa/setup
+//
+// Returns a new secret and its otpauth:// URI. Two-factor stays off until
+// the first code is confirmed through 2fa/enable.
+func SetupTwoFactor(c *gin.Context) {
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	secret, uri, err := twofactor.Setup(principal.ID, principal.Role)
+	if err != nil {
+		twoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"secret":           secret,
+		"provisioning_uri": uri,
+	})
+}
+
+// POST /<role>/2fa/enable
+func EnableTwoFactor(c *gin.Context) {
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	type EnableRequest struct {
+		Code string `json:"code" binding:"required"`
+	}
+
+	var req EnableRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	recoveryCodes, err := twofactor.Enable(principal.ID, principal.Role, req.Code)
+	if err != nil {
+		twoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Two-factor authentication enabled",
+		"recovery_codes": recoveryCodes,
+	})
+}
+
+// POST /<role>/2fa/disable
+func DisableTwoFactor(c *gin.Context) {
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	type DisableRequest struct {
+		Code string `json:"code" binding:"required"`
+	}
+
+	var req DisableRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	required, err := twofactor.Required(principal.Role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check two-factor status"})
+		return
+	}
+	if required {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Two-factor authentication is required for this account"})
+		return
+	}
+
+	if err := twofactor.Disable(principal.ID, principal.Role, req.Code); err != nil {
+		twoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+// POST /<role>/2fa/recoveryCodes
+//
+// Replaces every recovery code; the old ones stop working.
+func RegenerateRecoveryCodes(c *gin.Context) {
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	type RecoveryCodesRequest struct {
+		Code string `json:"code" binding:"required"`
+	}
+
+	var req RecoveryCodesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	recoveryCodes, err := twofactor.RegenerateRecoveryCodes(principal.ID, principal.Role, req.Code)
+	if err != nil {
+		twoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": recoveryCodes})
+}
+
+func twoFactorError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, twofactor.ErrInvalidCode):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid two-factor code"})
+	case errors.Is(err, twofactor.ErrInvalidChallenge):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired challenge, sign in again"})
+	case errors.Is(err, twofactor.ErrTooManyAttempts):
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many attempts, sign in again"})
+	case errors.Is(err, twofactor.ErrAlreadyEnabled):
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication already enabled"})
+	case errors.Is(err, twofactor.ErrNotEnrolled):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Two-factor authentication not set up"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Two-factor authentication failed"})
+	}
+}
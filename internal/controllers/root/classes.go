@@ -0,0 +1,116 @@
+package root_controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	dataprovider "github.com/hyphenXY/Streak-App/internal/dataproviders"
+	middlewares "github.com/hyphenXY/Streak-App/internal/middleware"
+)
+
+// POST /root/classes/:id/transfer
+//
+// Lets root hand over a class whose owner is gone. The receiving admin still
+// has to accept it.
+func ProposeClassTransfer(c *gin.Context) {
+	principal, exists := middlewares.GetPrincipal(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	classID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid class ID"})
+		return
+	}
+
+	var req struct {
+		ToAdminID uint   `json:"toAdminId" binding:"required"`
+		Note      string `json:"note" binding:"max=255"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	suspended, err := dataprovider.IsAdminSuspended(req.ToAdminID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Admin not found"})
+		return
+	}
+	if suspended {
+		c.JSON(http.StatusConflict, gin.H{"error": "Admin account suspended"})
+		return
+	}
+
+	transfer, err := dataprovider.ProposeClassTransfer(uint(classID), req.ToAdminID, principal.ID, "root", strings.TrimSpace(req.Note))
+	if err != nil {
+		classTransferError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":     "Transfer proposed. It takes effect once the receiving admin accepts it.",
+		"transfer_id": transfer.ID,
+		"class_id":    classID,
+		"to_admin_id": req.ToAdminID,
+	})
+}
+
+// DELETE /root/classes/:id/transfer
+func CancelClassTransfer(c *gin.Context) {
+	principal, exists := middlewares.GetPrincipal(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	classID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid class ID"})
+		return
+	}
+
+	if err := dataprovider.CancelClassTransfer(uint(classID), principal.ID, "root"); err != nil {
+		classTransferError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Transfer cancelled", "class_id": classID})
+}
+
+// GET /root/classes/:id/events
+func ClassEvents(c *gin.Context) {
+	classID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid class ID"})
+		return
+	}
+
+	events, err := dataprovider.ListClassEvents(uint(classID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch class events"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"class_id": classID, "events": events})
+}
+
+func classTransferError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, dataprovider.ErrClassNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Class not found"})
+	case errors.Is(err, dataprovider.ErrClassTransferNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "No pending transfer found"})
+	case errors.Is(err, dataprovider.ErrClassTransferPending):
+		c.JSON(http.StatusConflict, gin.H{"error": "This class already has a pending transfer"})
+	case errors.Is(err, dataprovider.ErrClassTransferToOwner):
+		c.JSON(http.StatusConflict, gin.H{"error": "That admin already owns this class"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update class transfer"})
+	}
+}
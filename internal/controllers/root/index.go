@@ -1,81 +1,307 @@
 package root_controller
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	auth_controller "github.com/hyphenXY/Streak-App/internal/controllers/auth"
+	dataprovider "github.com/hyphenXY/Streak-App/internal/dataproviders"
+	middlewares "github.com/hyphenXY/Streak-App/internal/middleware"
+	"github.com/hyphenXY/Streak-App/internal/models"
+	"github.com/hyphenXY/Streak-App/internal/utils"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
-// POST /user/signIn
+// POST /root/signIn
 func SignIn(c *gin.Context) {
-	// You can bind JSON here for email/password
+	// 1️⃣ Parse JSON body
 	type SignInRequest struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
+		UserName   string `json:"userName" binding:"required"`
+		Password   string `json:"password" binding:"required"`
+		DeviceName string `json:"deviceName"`
 	}
 
 	var req SignInRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	// 2️⃣ Unknown usernames go through the same lockout and bcrypt work as
+	// wrong passwords, so neither can be used to probe for accounts
+	req.UserName = auth_controller.NormalizeUserName(req.UserName)
+	lockoutKey := auth_controller.UserNameSignInKey(req.UserName)
+
+	var root models.Root
+	if err := dataprovider.DB.Where("user_name = ?", req.UserName).First(&root).Error; err != nil {
+		if auth_controller.CheckSignInAllowed(c, "root", lockoutKey, 0) {
+			auth_controller.DummyPasswordCheck(req.Password)
+			auth_controller.RejectSignIn(c, "root", lockoutKey, 0, "unknown username")
+		}
+		return
+	}
+
+	if !auth_controller.CheckSignInAllowed(c, "root", lockoutKey, root.ID) {
+		return
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(root.Password), []byte(req.Password)); err != nil {
+		auth_controller.RejectSignIn(c, "root", lockoutKey, root.ID, "wrong password")
+		return
+	}
+	auth_controller.SignInSucceeded(c)
+
+	if auth_controller.ChallengeTwoFactor(c, root.ID, "root", req.DeviceName) {
+		return
+	}
+
+	accessToken, err := auth_controller.StartSession(c, root.ID, "root", req.DeviceName)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start session"})
 		return
 	}
 
-	// TODO: authenticate user
 	c.JSON(http.StatusOK, gin.H{
-		"message": "User signed in successfully",
-		"email":   req.Email,
+		"message":      "Sign in successful",
+		"role":         "root",
+		"access_token": accessToken,
+		"user": gin.H{
+			"id":        root.ID,
+			"username":  root.UserName,
+			"email":     root.Email,
+			"firstName": root.FirstName,
+			"lastName":  root.LastName,
+			"phone":     root.Phone,
+		},
 	})
 }
 
-// POST /user/signUp
+// POST /root/register
+//
+// The very first root can register freely. After that, registration needs an
+// inviteToken issued by an existing root through POST /root/invite.
 func Register(c *gin.Context) {
-	type SignUpRequest struct {
-		Name     string `json:"name"`
-		Email    string `json:"email"`
-		Password string `json:"password"`
+	// 1️⃣ Parse JSON body
+	type RegisterRequest struct {
+		UserName    string `json:"userName" binding:"required"`
+		Password    string `json:"password" binding:"required"`
+		Email       string `json:"email" binding:"required,email"`
+		FirstName   string `json:"firstName" binding:"required"`
+		LastName    string `json:"lastName" binding:"required"`
+		Phone       string `json:"phone" binding:"required"`
+		DoB         string `json:"dob" binding:"required"`
+		InviteToken string `json:"inviteToken"`
 	}
 
-	var req SignUpRequest
+	var req RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	phone, err := utils.NormalizePhone(req.Phone)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid phone number"})
+		return
+	}
+	req.Phone = phone
+
+	req.UserName = strings.ToLower(strings.TrimSpace(req.UserName))
+	req.Email = strings.ToLower(strings.TrimSpace(req.Email))
+
+	dob, err := time.Parse("2006-01-02", req.DoB)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
+		return
+	}
+
+	var existingRoot models.Root
+	if err := dataprovider.DB.
+		Where("user_name = ? OR email = ?", req.UserName, req.Email).
+		First(&existingRoot).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "username, or email already exists."})
+		return
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+		return
+	}
+
+	taken, err := dataprovider.PhoneTaken(dataprovider.DB, "root", req.Phone, 0)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+		return
+	}
+	if taken {
+		c.JSON(http.StatusConflict, gin.H{"error": "Phone already in use by another account"})
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		return
+	}
+
+	newRoot := &models.Root{
+		UserName:  req.UserName,
+		Password:  string(hashedPassword),
+		Email:     req.Email,
+		FirstName: req.FirstName,
+		LastName:  req.LastName,
+		Phone:     req.Phone,
+		DOB:       dob,
+	}
+
+	// 2️⃣ Either bootstrap the first root or consume an invite
+	if req.InviteToken == "" {
+		err = dataprovider.CreateFirstRoot(newRoot)
+	} else {
+		err = dataprovider.CreateRootFromInvite(newRoot, utils.HashToken(req.InviteToken))
+	}
+	switch {
+	case errors.Is(err, dataprovider.ErrRootAlreadyExists):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Registration requires an invite from an existing root"})
+		return
+	case errors.Is(err, dataprovider.ErrInvalidRootInvite):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Invite is invalid or expired"})
+		return
+	case errors.Is(err, dataprovider.ErrRootInviteEmail):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Invite was issued for a different email"})
+		return
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		c.JSON(http.StatusConflict, gin.H{"error": "Phone already in use by another account"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create root"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Root created successfully",
+		"user": gin.H{
+			"id":        newRoot.ID,
+			"username":  newRoot.UserName,
+			"email":     newRoot.Email,
+			"firstName": newRoot.FirstName,
+			"lastName":  newRoot.LastName,
+			"phone":     newRoot.Phone,
+		},
+	})
+}
+
+// POST /root/invite
+func Invite(c *gin.Context) {
+	principal, exists := middlewares.GetPrincipal(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	type InviteRequest struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+
+	var req InviteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	token, err := utils.GenerateRefreshToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create invite token"})
+		return
+	}
+
+	invite := &models.RootInvite{
+		Email:           strings.ToLower(strings.TrimSpace(req.Email)),
+		TokenHash:       utils.HashToken(token),
+		InvitedByRootId: principal.ID,
+		Expiry:          time.Now().Add(72 * time.Hour),
+	}
+	if err := dataprovider.CreateRootInvite(invite); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create invite"})
 		return
 	}
 
-	// TODO: save user to DB
 	c.JSON(http.StatusCreated, gin.H{
-		"message": "User signed up successfully",
-		"name":    req.Name,
+		"message":      "Invite created",
+		"email":        invite.Email,
+		"invite_token": token,
+		"expires_at":   invite.Expiry,
 	})
 }
 
-// GET /user/homepage/:id
+// POST /root/signIn/2fa
+func SignInTwoFactor(c *gin.Context) {
+	auth_controller.CompleteTwoFactorSignIn(c, "root")
+}
+
+// POST /root/refreshToken
+func RefreshTokenRoot(c *gin.Context) {
+	auth_controller.RefreshSession(c, "root")
+}
+
+// POST /root/logOutRoot
+func LogOutRoot(c *gin.Context) {
+	auth_controller.EndSession(c)
+}
+
+// GET /root/homepage/:id
+//
+// Overview of the admins, classes and students across the app.
 func Homepage(c *gin.Context) {
-	userID := c.Param("id")
-	// TODO: fetch homepage data for user
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Homepage data",
-		"user_id": userID,
-	})
+	overview, err := dataprovider.GetRootOverview()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch overview"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"overview": overview})
 }
 
-// GET /user/profile/:id
+// GET /root/profile/:id
 func Profile(c *gin.Context) {
-	userID := c.Param("id")
-	// TODO: fetch user profile from DB
+	principal, exists := middlewares.GetPrincipal(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	root, err := dataprovider.GetRootProfile(principal.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch profile"})
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{
-		"user_id": userID,
-		"name":    "John Doe",
-		"email":   "john@example.com",
+		"id":         root.ID,
+		"first_name": root.FirstName,
+		"last_name":  root.LastName,
+		"email":      root.Email,
+		"phone":      root.Phone,
+		"user_name":  root.UserName,
+		"created_at": root.CreatedAt,
 	})
 }
 
-// PATCH /user/profile/:id
+// PATCH /root/profile/:id
 func UpdateProfile(c *gin.Context) {
-	userID := c.Param("id")
+	principal, exists := middlewares.GetPrincipal(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
 
 	type UpdateProfileRequest struct {
-		Name  string `json:"name"`
-		Email string `json:"email"`
+		FirstName string `json:"firstName"`
+		LastName  string `json:"lastName"`
+		Email     string `json:"email"`
 	}
 
 	var req UpdateProfileRequest
@@ -84,24 +310,277 @@ func UpdateProfile(c *gin.Context) {
 		return
 	}
 
-	// TODO: update profile in DB
+	updateData := map[string]interface{}{
+		"first_name": req.FirstName,
+		"last_name":  req.LastName,
+		"email":      strings.ToLower(strings.TrimSpace(req.Email)),
+	}
+
+	if err := dataprovider.UpdateRootProfile(updateData, principal.ID); err != nil {
+		switch {
+		case errors.Is(err, dataprovider.ErrEmailTaken):
+			c.JSON(http.StatusConflict, gin.H{"error": "Email already in use by another account"})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
+		}
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Profile updated",
-		"user_id": userID,
-		"name":    req.Name,
+		"user_id": principal.ID,
+		"name":    req.FirstName + " " + req.LastName,
 		"email":   req.Email,
 	})
 }
 
+// GET /root/admins?page=1&pageSize=20
+func ListAdmins(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page"})
+		return
+	}
+	pageSize, err := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	if err != nil || pageSize < 1 || pageSize > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "pageSize must be between 1 and 100"})
+		return
+	}
+
+	admins, total, classCounts, err := dataprovider.ListAdmins(page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch admins"})
+		return
+	}
+
+	list := make([]gin.H, 0, len(admins))
+	for _, a := range admins {
+		list = append(list, gin.H{
+			"id":           a.ID,
+			"username":     a.UserName,
+			"email":        a.Email,
+			"firstName":    a.FirstName,
+			"lastName":     a.LastName,
+			"phone":        a.Phone,
+			"suspended":    a.SuspendedAt != nil,
+			"suspended_at": a.SuspendedAt,
+			"class_count":  classCounts[a.ID],
+			"created_at":   a.CreatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"admins":   list,
+		"page":     page,
+		"pageSize": pageSize,
+		"total":    total,
+	})
+}
+
+// POST /root/admin/:id/suspend
+func SuspendAdmin(c *gin.Context) {
+	setAdminSuspended(c, true)
+}
+
+// POST /root/admin/:id/reinstate
+func ReinstateAdmin(c *gin.Context) {
+	setAdminSuspended(c, false)
+}
+
+func setAdminSuspended(c *gin.Context, suspended bool) {
+	adminID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid admin ID"})
+		return
+	}
+
+	if err := dataprovider.SetAdminSuspended(uint(adminID), suspended); err != nil {
+		if errors.Is(err, dataprovider.ErrAdminNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Admin not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update admin"})
+		return
+	}
+
+	message := "Admin reinstated"
+	if suspended {
+		message = "Admin suspended"
+	}
+	c.JSON(http.StatusOK, gin.H{"message": message, "admin_id": adminID, "suspended": suspended})
+}
+
+// DELETE /root/admin/:id?classes=delete
+// DELETE /root/admin/:id?classes=transfer&transferTo=<adminId>
+//
+// An admin who still owns classes can only be deleted once the caller says
+// what should happen to those classes.
 func DeleteAdmin(c *gin.Context) {
-	userID := c.Param("id")
-	// TODO: delete admin user from DB
+	principal, exists := middlewares.GetPrincipal(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	adminID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid admin ID"})
+		return
+	}
+
+	classCount, err := dataprovider.CountClassesByAdmin(uint(adminID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count admin classes"})
+		return
+	}
+
+	policy := c.Query("classes")
+	var transferTo uint64
+	switch {
+	case classCount == 0:
+		// nothing to decide
+	case policy == dataprovider.AdminClassesDelete:
+		// owned classes go away with the admin
+	case policy == dataprovider.AdminClassesTransfer:
+		transferTo, err = strconv.ParseUint(c.Query("transferTo"), 10, 64)
+		if err != nil || transferTo == adminID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "transferTo must be the ID of another admin"})
+			return
+		}
+		suspended, err := dataprovider.IsAdminSuspended(uint(transferTo))
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Transfer target admin not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check transfer target"})
+			return
+		}
+		if suspended {
+			c.JSON(http.StatusConflict, gin.H{"error": "Cannot transfer classes to a suspended admin"})
+			return
+		}
+	default:
+		c.JSON(http.StatusConflict, gin.H{
+			"error":       "Admin owns classes; pass classes=delete or classes=transfer&transferTo=<adminId>",
+			"class_count": classCount,
+		})
+		return
+	}
+
+	affected, err := dataprovider.DeleteAdmin(uint(adminID), policy, uint(transferTo), principal.ID)
+	if err != nil {
+		if errors.Is(err, dataprovider.ErrAdminNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Admin not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete admin"})
+		return
+	}
+
+	response := gin.H{
+		"message":  "Admin deleted",
+		"admin_id": adminID,
+	}
+	switch {
+	case affected == 0:
+		response["classes"] = gin.H{"action": "none", "count": 0}
+	case policy == dataprovider.AdminClassesTransfer:
+		response["classes"] = gin.H{"action": "transferred", "count": affected, "transferred_to": transferTo}
+	default:
+		response["classes"] = gin.H{"action": "deleted", "count": affected}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GET /root/authEvents?role=admin&subjectId=7&type=sign_in_failure&since=2024-01-02T15:04:05Z&page=1&pageSize=20
+//
+// Auth events of every account. All filters are optional.
+func ListAuthEvents(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page"})
+		return
+	}
+	pageSize, err := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	if err != nil || pageSize < 1 || pageSize > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "pageSize must be between 1 and 100"})
+		return
+	}
+
+	filter := dataprovider.AuthEventFilter{
+		Role: c.Query("role"),
+		Type: c.Query("type"),
+	}
+	if subjectID := c.Query("subjectId"); subjectID != "" {
+		id, err := strconv.ParseUint(subjectID, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid subjectId"})
+			return
+		}
+		uid := uint(id)
+		filter.SubjectID = &uid
+	}
+	if since := c.Query("since"); since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "since must be an RFC 3339 timestamp"})
+			return
+		}
+		filter.Since = &t
+	}
+
+	events, total, err := dataprovider.ListAuthEvents(filter, page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch auth events"})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Admin user deleted",
-		"user_id": userID,
+		"events":   auth_controller.AuthEventList(events),
+		"page":     page,
+		"pageSize": pageSize,
+		"total":    total,
 	})
 }
 
+// GET /root/settings
+func Settings(c *gin.Context) {
+	requireAdmin2FA, err := dataprovider.GetBoolSetting(models.SettingRequireAdmin2FA)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch settings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"requireAdmin2FA": requireAdmin2FA})
+}
+
+// PATCH /root/settings
+//
+// With requireAdmin2FA on, admins without two-factor authentication have to
+// set it up during their next sign-in and can no longer turn it off.
+func UpdateSettings(c *gin.Context) {
+	type UpdateSettingsRequest struct {
+		RequireAdmin2FA *bool `json:"requireAdmin2FA"`
+	}
+
+	var req UpdateSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	if req.RequireAdmin2FA != nil {
+		if err := dataprovider.SetBoolSetting(models.SettingRequireAdmin2FA, *req.RequireAdmin2FA); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update settings"})
+			return
+		}
+	}
+
+	Settings(c)
+}
+
 func HealthCheck(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "success",
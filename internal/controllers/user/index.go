@@ -1,18 +1,18 @@
 package user_controller
 
 import (
-	"bytes"
-	"encoding/json"
 	"errors"
 	"net/http"
-	"os"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	auth_controller "github.com/hyphenXY/Streak-App/internal/controllers/auth"
 	dataprovider "github.com/hyphenXY/Streak-App/internal/dataproviders"
+	middlewares "github.com/hyphenXY/Streak-App/internal/middleware"
 	"github.com/hyphenXY/Streak-App/internal/models"
+	"github.com/hyphenXY/Streak-App/internal/otp"
 	"github.com/hyphenXY/Streak-App/internal/utils"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
@@ -22,8 +22,9 @@ import (
 func SignIn(c *gin.Context) {
 	// 1️⃣ Parse JSON body
 	type SignInRequest struct {
-		UserName string `json:"userName" binding:"required"`
-		Password string `json:"password" binding:"required"`
+		UserName   string `json:"userName" binding:"required"`
+		Password   string `json:"password" binding:"required"`
+		DeviceName string `json:"deviceName"`
 	}
 
 	var req SignInRequest
@@ -32,52 +33,185 @@ func SignIn(c *gin.Context) {
 		return
 	}
 
+	// 2️⃣ Unknown usernames go through the same lockout and bcrypt work as
+	// wrong passwords, so neither can be used to probe for accounts
+	req.UserName = auth_controller.NormalizeUserName(req.UserName)
+	lockoutKey := auth_controller.UserNameSignInKey(req.UserName)
+
 	var user models.User
 	if err := dataprovider.DB.Where("user_name = ?", req.UserName).First(&user).Error; err != nil {
-		// User not found
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid username! try to remember it."})
+		if auth_controller.CheckSignInAllowed(c, "user", lockoutKey, 0) {
+			auth_controller.DummyPasswordCheck(req.Password)
+			auth_controller.RejectSignIn(c, "user", lockoutKey, 0, "unknown username")
+		}
+		return
+	}
+
+	if !auth_controller.CheckSignInAllowed(c, "user", lockoutKey, user.ID) {
+		return
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		auth_controller.RejectSignIn(c, "user", lockoutKey, user.ID, "wrong password")
 		return
 	}
+	auth_controller.SignInSucceeded(c)
 
-	err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password))
+	accessToken, err := auth_controller.StartSession(c, user.ID, "user", req.DeviceName)
 	if err != nil {
-		// Wrong password
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid password! work your brain or reset it."})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start session"})
 		return
 	}
 
-	accessToken, err := utils.GenerateJWT(map[string]any{
-		"userId": user.ID,
-		"role":   "user",
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Sign in successful",
+		"role":         "user",
+		"access_token": accessToken,
+		"user": gin.H{
+			"id":        user.ID,
+			"username":  user.UserName,
+			"email":     user.Email,
+			"firstName": user.FirstName,
+			"lastName":  user.LastName,
+			"phone":     user.Phone,
+		},
 	})
+}
+
+// POST /user/signIn/otp/send
+//
+// Sends a login OTP if an account is registered to the phone. The response is
+// the same either way so it can't be used to probe for accounts.
+func SendSignInOTP(c *gin.Context) {
+	type SendSignInOTPRequest struct {
+		Phone string `json:"phone" binding:"required"`
+	}
+
+	var req SendSignInOTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	phone, err := utils.NormalizePhone(req.Phone)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create token"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid phone number"})
+		return
+	}
+	req.Phone = phone
+
+	account, err := dataprovider.GetUserByPhone(dataprovider.DB, req.Phone)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
 		return
 	}
 
-	refreshToken, err := utils.GenerateRefreshToken()
+	if account != nil {
+		// Codes only go by email to an address that has been verified
+		email := ""
+		if account.EmailVerifiedAt != nil {
+			email = account.Email
+		}
+		if err := otp.SendVia(c.Request.Context(), "user", account.OTPChannel, req.Phone, email, models.OTPPurposeLogin, c.ClientIP()); err != nil {
+			var limited *otp.RateLimitError
+			switch {
+			case errors.As(err, &limited):
+				c.Header("Retry-After", strconv.Itoa(limited.RetryAfterSeconds()))
+				c.JSON(http.StatusTooManyRequests, gin.H{"error": limited.Error(), "retry_after": limited.RetryAfterSeconds()})
+			case errors.Is(err, otp.ErrInvalidPhone):
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid phone number"})
+			case errors.Is(err, otp.ErrDeliveryFailed):
+				c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to send OTP"})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store OTP"})
+			}
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "If an account uses this phone, an OTP has been sent",
+		"purpose": models.OTPPurposeLogin,
+	})
+}
+
+// POST /user/signIn/otp
+//
+// Passwordless sign-in: verifies the login OTP sent by signIn/otp/send and
+// starts a session exactly like SignIn.
+func SignInWithOTP(c *gin.Context) {
+	// 1️⃣ Parse JSON body
+	type SignInWithOTPRequest struct {
+		Phone      string `json:"phone" binding:"required"`
+		OTP        string `json:"otp" binding:"required"`
+		DeviceName string `json:"deviceName"`
+	}
+
+	var req SignInWithOTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	phone, err := utils.NormalizePhone(req.Phone)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create refresh token"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid phone number"})
 		return
 	}
+	req.Phone = phone
 
-	refreshTokenExpiry := time.Now().Add(30 * 24 * time.Hour) // 30 days
-	if err := dataprovider.UpdateUserRefreshToken(user.ID, refreshToken, refreshTokenExpiry); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update refresh token"})
+	// 2️⃣ Wrong codes count against the sign-in lockout like wrong passwords,
+	// keyed on the phone
+	lockoutKey := auth_controller.PhoneSignInKey(req.Phone)
+	var subjectID uint
+	if account, err := dataprovider.GetUserByPhone(dataprovider.DB, req.Phone); err == nil {
+		subjectID = account.ID
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+		return
+	}
+	if !auth_controller.CheckSignInAllowed(c, "user", lockoutKey, subjectID) {
 		return
 	}
 
-	// Set refresh token as HttpOnly cookie
-	secure := gin.Mode() == gin.ReleaseMode
-	c.SetCookie(
-		"refresh_token",
-		refreshToken,
-		int((30 * 24 * time.Hour).Seconds()), // expiry in seconds
-		"/",
-		"",     // domain (empty = current domain)
-		secure, // secure (true = HTTPS only)
-		true,   // httpOnly
-	)
+	// 3️⃣ Verify the code and spend the resulting ticket straight away
+	var user *models.User
+	ticket, err := otp.Verify("user", req.Phone, models.OTPPurposeLogin, req.OTP)
+	if err == nil {
+		err = otp.Redeem("user", req.Phone, models.OTPPurposeLogin, ticket, func(tx *gorm.DB) error {
+			var err error
+			user, err = dataprovider.GetUserByPhone(tx, req.Phone)
+			return err
+		})
+	}
+	if err != nil {
+		var limited *otp.RateLimitError
+		switch {
+		case errors.As(err, &limited):
+			auth_controller.RecordSignInFailure(c, "user", lockoutKey, subjectID, "OTP locked")
+			c.Header("Retry-After", strconv.Itoa(limited.RetryAfterSeconds()))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": limited.Error(), "retry_after": limited.RetryAfterSeconds()})
+		case errors.Is(err, otp.ErrInvalidPhone):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid phone number"})
+		case errors.Is(err, otp.ErrExpiredOTP):
+			auth_controller.RecordSignInFailure(c, "user", lockoutKey, subjectID, "OTP expired")
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "OTP expired"})
+		case errors.Is(err, otp.ErrInvalidOTP), errors.Is(err, otp.ErrInvalidTicket), errors.Is(err, gorm.ErrRecordNotFound):
+			auth_controller.RecordSignInFailure(c, "user", lockoutKey, subjectID, "wrong OTP")
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid OTP"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "OTP sign-in failed"})
+		}
+		return
+	}
+	auth_controller.SignInSucceeded(c)
+
+	// 4️⃣ Start a session
+	accessToken, err := auth_controller.StartSession(c, user.ID, "user", req.DeviceName)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start session"})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message":      "Sign in successful",
@@ -104,7 +238,8 @@ func SignUp(c *gin.Context) {
 		LastName  string `json:"lastName" binding:"required"`
 		Phone     string `json:"phone" binding:"required"`
 		DoB       string `json:"dob" binding:"required"`
-		OTP       string `json:"otp" binding:"required"`
+		// Returned by verifyOTP for purpose "signup"
+		VerificationTicket string `json:"verificationTicket" binding:"required"`
 	}
 	var req SignUpRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -112,21 +247,12 @@ func SignUp(c *gin.Context) {
 		return
 	}
 
-	// Verify OTP
-	phoneUint, err := strconv.ParseUint(req.Phone, 10, 64)
+	phone, err := utils.NormalizePhone(req.Phone)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid phone number"})
 		return
 	}
-	isValid, err := dataprovider.IsPhoneVerified(uint(phoneUint))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify phone"})
-		return
-	}
-	if !isValid {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Phone not verified"})
-		return
-	}
+	req.Phone = phone
 
 	req.UserName = strings.ToLower(strings.TrimSpace(req.UserName))
 	req.Email = strings.ToLower(strings.TrimSpace(req.Email))
@@ -149,6 +275,17 @@ func SignUp(c *gin.Context) {
 		return
 	}
 
+	// Phone OTP sign-in and password reset find the account by phone
+	taken, err := dataprovider.PhoneTaken(dataprovider.DB, "user", req.Phone, 0)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+		return
+	}
+	if taken {
+		c.JSON(http.StatusConflict, gin.H{"error": "Phone already in use by another account"})
+		return
+	}
+
 	dob, err := time.Parse("2006-01-02", req.DoB)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
@@ -165,8 +302,31 @@ func SignUp(c *gin.Context) {
 		DOB:       dob, // parsed time
 	}
 
-	if err := dataprovider.CreateUser(newUser); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+	// 2️⃣ Spend the phone verification ticket and create the account together,
+	// joining any classes an admin imported this phone into
+	var joinedClasses []uint
+	err = otp.Redeem("user", req.Phone, models.OTPPurposeSignup, req.VerificationTicket, func(tx *gorm.DB) error {
+		if err := tx.Create(newUser).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return dataprovider.ErrPhoneTaken
+			}
+			return err
+		}
+		joined, err := dataprovider.ClaimPlaceholders(tx, newUser.ID, req.Phone, "")
+		joinedClasses = joined
+		return err
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, otp.ErrInvalidPhone):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid phone number"})
+		case errors.Is(err, otp.ErrInvalidTicket):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Phone not verified"})
+		case errors.Is(err, dataprovider.ErrPhoneTaken):
+			c.JSON(http.StatusConflict, gin.H{"error": "Phone already in use by another account"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+		}
 		return
 	}
 
@@ -178,21 +338,22 @@ func SignUp(c *gin.Context) {
 			"lastName":  req.LastName,
 			"phone":     req.Phone,
 		},
+		"joined_class_ids": joinedClasses,
 	})
 
 }
 
 // GET /user/classList/:id
 func ClassList(c *gin.Context) {
-	userID, err := c.Get("userId")
-	if !err {
+	principal, exists := middlewares.GetPrincipal(c)
+	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
 		return
 	}
 
 	// TODO: fetch class list data for user
 	var userClasses []models.User_Classes
-	if err := dataprovider.DB.Where("user_id = ?", userID).Find(&userClasses).Error; err != nil {
+	if err := dataprovider.DB.Where("user_id = ? AND inactive_at IS NULL", principal.ID).Find(&userClasses).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user classes"})
 		return
 	}
@@ -204,7 +365,11 @@ func ClassList(c *gin.Context) {
 		classIDs = append(classIDs, uc.ClassID)
 	}
 	if len(classIDs) > 0 {
-		if err := dataprovider.DB.Where("id IN ?", classIDs).Find(&classes).Error; err != nil {
+		query := dataprovider.DB.Where("id IN ?", classIDs)
+		if c.Query("includeArchived") != "true" {
+			query = query.Where("archived_at IS NULL")
+		}
+		if err := query.Find(&classes).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch class details"})
 			return
 		}
@@ -219,12 +384,12 @@ func ClassList(c *gin.Context) {
 		joinedClasses = append(joinedClasses, gin.H{
 			"class_id":            class.ID,
 			"class_name":          class.Name,
-			"class_code":          class.ClassCode,
 			"created_at":          class.CreatedAt,
 			"joined_at":           classJoinMap[class.ID],
 			"email":               class.Email,
 			"phone":               class.Phone,
 			"created_by_admin_id": class.CreatedByAdminId,
+			"archived":            class.ArchivedAt != nil,
 		})
 	}
 	c.JSON(http.StatusOK, gin.H{
@@ -235,13 +400,13 @@ func ClassList(c *gin.Context) {
 // POST /user/markAttendance/:id
 func MarkAttendance(c *gin.Context) {
 	// check if user is enrolled in the class
-	userIDVal, exists := c.Get("userId")
+	principal, exists := middlewares.GetPrincipal(c)
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
 		return
 	}
 
-	classID, exists := c.Get("classID")
+	classID, exists := middlewares.GetClassID(c)
 	if !exists {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "classID not provided"})
 		return
@@ -256,16 +421,7 @@ func MarkAttendance(c *gin.Context) {
 		return
 	}
 
-	classIDFloat, ok2 := classID.(uint)
-
-	if !ok2 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid userId or classID type"})
-		return
-	}
-
-	println("d", uint(userIDVal.(float64)), classIDFloat)
-
-	err := dataprovider.MarkAttendanceByUser(classIDFloat, uint(userIDVal.(float64)), req.Status)
+	err := dataprovider.MarkAttendanceByUser(classID, principal.ID, req.Status)
 	if err != nil {
 		// Check if attendance is already marked
 		if err.Error() == "already marked" {
@@ -281,14 +437,14 @@ func MarkAttendance(c *gin.Context) {
 
 // GET /user/profile/:id
 func Profile(c *gin.Context) {
-	userID, exists := c.Get("userId")
+	principal, exists := middlewares.GetPrincipal(c)
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
 
 	var user models.User
-	if err := dataprovider.DB.Where("id = ?", userID).First(&user).Error; err != nil {
+	if err := dataprovider.DB.Where("id = ?", principal.ID).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 			return
@@ -298,14 +454,16 @@ func Profile(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"id":    user.ID,
-		"name":  user.FirstName + " " + user.LastName,
-		"email": user.Email,
+		"id":             user.ID,
+		"name":           user.FirstName + " " + user.LastName,
+		"email":          user.Email,
+		"email_verified": user.EmailVerifiedAt != nil,
+		"otp_channel":    user.OTPChannel,
 	})
 }
 
 func UpdateProfile(c *gin.Context) {
-	userID, exists := c.Get("UserId")
+	principal, exists := middlewares.GetPrincipal(c)
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
@@ -329,12 +487,21 @@ func UpdateProfile(c *gin.Context) {
 		"email":      strings.ToLower(strings.TrimSpace(req.Email)),
 	}
 
-	dataprovider.UpdateProfile(updateData, uint(userID.(float64)))
+	if err := dataprovider.UpdateProfile(updateData, principal.ID); err != nil {
+		switch {
+		case errors.Is(err, dataprovider.ErrEmailTaken):
+			c.JSON(http.StatusConflict, gin.H{"error": "Email already in use by another account"})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
+		}
+		return
+	}
 
-	// TODO: update profile in DB using updateData
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Profile updated",
-		"user_id": userID,
+		"user_id": principal.ID,
 		"name":    req.FirstName + " " + req.LastName,
 		"email":   req.Email,
 	})
@@ -343,7 +510,8 @@ func UpdateProfile(c *gin.Context) {
 // POST /user/sendOTP
 func SendOTP(c *gin.Context) {
 	type OTPRequest struct {
-		Phone string `json:"phone"`
+		Phone   string `json:"phone" binding:"required"`
+		Purpose string `json:"purpose"`
 	}
 
 	var req OTPRequest
@@ -352,243 +520,418 @@ func SendOTP(c *gin.Context) {
 		return
 	}
 
-	otp, err := utils.GenerateOTP()
+	phone, err := utils.NormalizePhone(req.Phone)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate OTP"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid phone number"})
 		return
 	}
+	req.Phone = phone
 
-	payload := map[string]string{
-		"phone":       "+91" + req.Phone,
-		"otp":         otp,
-		"gateway_key": os.Getenv("FAZPASS_GATEWAY_KEY"),
+	// Other purposes have their own endpoints, which check the account first
+	if req.Purpose == "" {
+		req.Purpose = models.OTPPurposeSignup
 	}
-
-	payloadBytes, err := json.Marshal(payload)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to marshal payload"})
+	if req.Purpose != models.OTPPurposeSignup {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid OTP purpose"})
 		return
 	}
 
-	url := "https://api.fazpass.com/v1/otp/send"
-
-	httpReq, err := http.NewRequest("POST", url, bytes.NewBuffer(payloadBytes))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create HTTP request"})
+	if err := otp.Send(c.Request.Context(), "user", req.Phone, req.Purpose, c.ClientIP()); err != nil {
+		var limited *otp.RateLimitError
+		switch {
+		case errors.As(err, &limited):
+			c.Header("Retry-After", strconv.Itoa(limited.RetryAfterSeconds()))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": limited.Error(), "retry_after": limited.RetryAfterSeconds()})
+		case errors.Is(err, otp.ErrInvalidPhone):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid phone number"})
+		case errors.Is(err, otp.ErrInvalidPurpose):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid OTP purpose"})
+		case errors.Is(err, otp.ErrDeliveryFailed):
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to send OTP"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store OTP"})
+		}
 		return
 	}
 
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Authorization", "Bearer "+os.Getenv("FAZPASS_MERCHANT_KEY"))
+	c.JSON(http.StatusOK, gin.H{
+		"message": "OTP sent",
+		"phone":   req.Phone,
+		"purpose": req.Purpose,
+	})
+}
 
-	client := &http.Client{}
-	resp, err := client.Do(httpReq)
-	if err != nil {
-		// Print the error for debugging
-		println("Error sending OTP:", err.Error())
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send OTP"})
-		return
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		buf := new(bytes.Buffer)
-		buf.ReadFrom(resp.Body)
-		errorMsg := buf.String()
-		println("Failed to send OTP, status code:", resp.Status, "response:", errorMsg)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":        "Failed to send OTP",
-			"status_code":  resp.StatusCode,
-			"status":       resp.Status,
-			"response_msg": errorMsg,
-		})
+// POST /user/verifyOTP
+func VerifyOTP(c *gin.Context) {
+	type VerifyRequest struct {
+		Phone   string `json:"phone" binding:"required"`
+		OTP     string `json:"otp" binding:"required"`
+		Purpose string `json:"purpose"`
+	}
+
+	var req VerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	phoneUint, err := strconv.ParseUint(req.Phone, 10, 64)
+	phone, err := utils.NormalizePhone(req.Phone)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid phone number"})
 		return
 	}
-	err = dataprovider.StoreOTP(uint(phoneUint), otp)
+	req.Phone = phone
+
+	// Other purposes have their own endpoints, which check the account first
+	if req.Purpose == "" {
+		req.Purpose = models.OTPPurposeSignup
+	}
+	if req.Purpose != models.OTPPurposeSignup {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid OTP purpose"})
+		return
+	}
+
+	ticket, err := otp.Verify("user", req.Phone, req.Purpose, req.OTP)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store OTP"})
+		var limited *otp.RateLimitError
+		switch {
+		case errors.As(err, &limited):
+			c.Header("Retry-After", strconv.Itoa(limited.RetryAfterSeconds()))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": limited.Error(), "retry_after": limited.RetryAfterSeconds()})
+		case errors.Is(err, otp.ErrInvalidPhone):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid phone number"})
+		case errors.Is(err, otp.ErrInvalidPurpose):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid OTP purpose"})
+		case errors.Is(err, otp.ErrExpiredOTP):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "OTP expired"})
+		case errors.Is(err, otp.ErrInvalidOTP):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "OTP not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "OTP verification failed"})
+		}
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "OTP sent",
-		"phone":   req.Phone,
+		"message":             "OTP verified successfully",
+		"purpose":             req.Purpose,
+		"verification_ticket": ticket,
+		"expires_in":          int(otp.TicketTTL.Seconds()),
 	})
 }
 
-func VerifyOTP(c *gin.Context) {
-	type VerifyRequest struct {
-		Phone string `json:"phone"`
-		OTP   string `json:"otp"`
+func Enroll(c *gin.Context) {
+	principal, exists := middlewares.GetPrincipal(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user_id not found in context"})
+		return
 	}
 
-	var req VerifyRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	classCode := utils.NormalizeClassCode(c.Param("classCode"))
+
+	classID, pending, err := dataprovider.EnrollWithClassCode(principal.ID, classCode)
+	if err != nil {
+		switch {
+		case errors.Is(err, dataprovider.ErrClassCodeNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Class code not found"})
+		case errors.Is(err, dataprovider.ErrClassCodeInactive):
+			c.JSON(http.StatusGone, gin.H{"error": "Class code has expired or reached its limit"})
+		case errors.Is(err, dataprovider.ErrClassArchived):
+			c.JSON(http.StatusConflict, gin.H{"error": "Class is archived"})
+		case errors.Is(err, dataprovider.ErrAlreadyEnrolled):
+			c.JSON(http.StatusConflict, gin.H{"error": "User already enrolled"})
+		case errors.Is(err, dataprovider.ErrEnrollmentPending):
+			c.JSON(http.StatusConflict, gin.H{"error": "Enrollment request already pending"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enroll user"})
+		}
 		return
 	}
 
-	phoneUint, err := strconv.ParseUint(req.Phone, 10, 64)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid phone number"})
+	if pending {
+		c.JSON(http.StatusAccepted, gin.H{
+			"message":  "Enrollment requested. An admin of the class has to approve it.",
+			"status":   models.EnrollmentRequestPending,
+			"user_id":  principal.ID,
+			"class_id": classID,
+		})
 		return
 	}
 
-	isValid, err := dataprovider.VerifyOTP(uint(phoneUint), req.OTP)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify OTP"})
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "User enrolled",
+		"user_id":  principal.ID,
+		"class_id": classID,
+	})
+}
+
+// POST /user/enroll/invite/:token
+//
+// Same as Enroll, for the signed invite links and QR codes admins hand out.
+func EnrollWithInvite(c *gin.Context) {
+	principal, exists := middlewares.GetPrincipal(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user_id not found in context"})
 		return
 	}
-	switch isValid {
-	case "Verified!":
-		err = dataprovider.MarkPhoneVerified(uint(phoneUint))
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark phone as verified"})
+
+	invite, err := utils.ParseClassInviteToken(c.Param("token"))
+	if err != nil {
+		if errors.Is(err, utils.ErrTokenExpired) {
+			c.JSON(http.StatusGone, gin.H{"error": "Invite has expired"})
 			return
 		}
-		c.JSON(http.StatusOK, gin.H{"message": "OTP verified successfully"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invite"})
 		return
-	case "Expired!":
-		c.JSON(http.StatusBadRequest, gin.H{"error": "OTP expired"})
+	}
+
+	pending, err := dataprovider.EnrollWithClassInvite(principal.ID, invite.ClassID, invite.IssuedBy, invite.Epoch)
+	if err != nil {
+		switch {
+		case errors.Is(err, dataprovider.ErrClassInviteRevoked):
+			c.JSON(http.StatusGone, gin.H{"error": "Invite is no longer valid"})
+		case errors.Is(err, dataprovider.ErrClassNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Class not found"})
+		case errors.Is(err, dataprovider.ErrClassArchived):
+			c.JSON(http.StatusConflict, gin.H{"error": "Class is archived"})
+		case errors.Is(err, dataprovider.ErrAlreadyEnrolled):
+			c.JSON(http.StatusConflict, gin.H{"error": "User already enrolled"})
+		case errors.Is(err, dataprovider.ErrEnrollmentPending):
+			c.JSON(http.StatusConflict, gin.H{"error": "Enrollment request already pending"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enroll user"})
+		}
+		return
+	}
+
+	if pending {
+		c.JSON(http.StatusAccepted, gin.H{
+			"message":  "Enrollment requested. An admin of the class has to approve it.",
+			"status":   models.EnrollmentRequestPending,
+			"user_id":  principal.ID,
+			"class_id": invite.ClassID,
+		})
 		return
-	case "Wrong!":
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "OTP not found"})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "User enrolled",
+		"user_id":  principal.ID,
+		"class_id": invite.ClassID,
+	})
+}
+
+// POST /user/leaveClass/:classID
+//
+// The student's attendance is kept, and enrolling again picks it back up.
+func LeaveClass(c *gin.Context) {
+	principal, exists := middlewares.GetPrincipal(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
 		return
-	case "Failed!":
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "OTP verification failed"})
+	}
+	classID, exists := middlewares.GetClassID(c)
+	if !exists {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "classID not provided"})
 		return
-	default:
-		c.JSON(http.StatusBadRequest, gin.H{"error": isValid})
+	}
+
+	if err := dataprovider.DeactivateEnrollment(principal.ID, classID, nil); err != nil {
+		if errors.Is(err, dataprovider.ErrNotEnrolled) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not enrolled in this class"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to leave class"})
 		return
 	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Left class", "class_id": classID})
 }
 
-func Enroll(c *gin.Context) {
-	classCode := c.Param("classCode")
+// GET /user/enrollmentRequests
+func EnrollmentRequests(c *gin.Context) {
+	principal, exists := middlewares.GetPrincipal(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
 
-	classID, err := dataprovider.GetClassIDByCode(classCode)
+	requests, err := dataprovider.ListUserEnrollmentRequests(principal.ID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Class code not found"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch enrollment requests"})
 		return
 	}
 
-	// Log the enrollment attempt
+	c.JSON(http.StatusOK, gin.H{"requests": requests})
+}
 
-	userIDVal, exists := c.Get("userId")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user_id not found in context"})
-		return
+// POST /user/forgotPassword
+//
+// Sends a password_reset OTP if an account is registered to the phone. The
+// response is the same either way so it can't be used to probe for accounts.
+func ForgotPassword(c *gin.Context) {
+	type ForgotPasswordRequest struct {
+		Phone string `json:"phone" binding:"required"`
 	}
 
-	userID, ok := userIDVal.(float64)
-	if !ok {
-		// if your middleware stored it as float64 or string, convert accordingly
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "invalid user_id type in context"})
+	var req ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
 		return
 	}
 
-	// check if user is already enrolled
-	var existingEnrollment models.User_Classes
-	isEnrolled, err := dataprovider.IfAlreadyEnrolled(uint(userID), uint(classID), &existingEnrollment)
+	phone, err := utils.NormalizePhone(req.Phone)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check enrollment status"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid phone number"})
 		return
 	}
-	if isEnrolled {
-		c.JSON(http.StatusConflict, gin.H{"error": "User already enrolled"})
+	req.Phone = phone
+
+	account, err := dataprovider.GetUserByPhone(dataprovider.DB, req.Phone)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
 		return
 	}
 
-	err = dataprovider.EnrollUser(uint(userID), uint(classID))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enroll user"})
-		return
+	if account != nil {
+		// Codes only go by email to an address that has been verified
+		email := ""
+		if account.EmailVerifiedAt != nil {
+			email = account.Email
+		}
+		if err := otp.SendVia(c.Request.Context(), "user", account.OTPChannel, req.Phone, email, models.OTPPurposePasswordReset, c.ClientIP()); err != nil {
+			var limited *otp.RateLimitError
+			switch {
+			case errors.As(err, &limited):
+				c.Header("Retry-After", strconv.Itoa(limited.RetryAfterSeconds()))
+				c.JSON(http.StatusTooManyRequests, gin.H{"error": limited.Error(), "retry_after": limited.RetryAfterSeconds()})
+			case errors.Is(err, otp.ErrInvalidPhone):
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid phone number"})
+			case errors.Is(err, otp.ErrDeliveryFailed):
+				c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to send OTP"})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store OTP"})
+			}
+			return
+		}
 	}
 
-	// TODO: enroll req.UserID in classID
 	c.JSON(http.StatusOK, gin.H{
-		"message":  "User enrolled",
-		"user_id":  userID,
-		"class_id": classID,
+		"message": "If an account uses this phone, an OTP has been sent",
+		"purpose": models.OTPPurposePasswordReset,
 	})
 }
 
-func RefreshTokenUser(c *gin.Context) {
-	// 1️⃣ Extract refresh token from cookie
-	refreshToken, err := c.Cookie("refresh_token")
-	if err != nil || refreshToken == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "refresh token missing"})
+// POST /user/forgotPassword/verify
+//
+// Verifies the OTP sent by forgotPassword and returns the ticket that
+// resetPassword takes.
+func VerifyResetOTP(c *gin.Context) {
+	type VerifyResetOTPRequest struct {
+		Phone string `json:"phone" binding:"required"`
+		OTP   string `json:"otp" binding:"required"`
+	}
+
+	var req VerifyResetOTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
 		return
 	}
 
-	// 2️⃣ Find user with this refresh token
-	var user models.User
-	if err := dataprovider.DB.
-		Where("refresh_token = ?", refreshToken).
-		First(&user).Error; err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid refresh token"})
+	phone, err := utils.NormalizePhone(req.Phone)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid phone number"})
 		return
 	}
+	req.Phone = phone
 
-	// 3️⃣ Check expiry
-	if time.Now().After(*user.RefreshTokenExpiry) {
-		// clear cookie
-		c.SetCookie("refresh_token", "", -1, "/", "", true, true)
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "refresh token expired"})
+	ticket, err := otp.Verify("user", req.Phone, models.OTPPurposePasswordReset, req.OTP)
+	if err != nil {
+		var limited *otp.RateLimitError
+		switch {
+		case errors.As(err, &limited):
+			c.Header("Retry-After", strconv.Itoa(limited.RetryAfterSeconds()))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": limited.Error(), "retry_after": limited.RetryAfterSeconds()})
+		case errors.Is(err, otp.ErrExpiredOTP):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "OTP expired"})
+		case errors.Is(err, otp.ErrInvalidOTP):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid OTP"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "OTP verification failed"})
+		}
 		return
 	}
 
-	// 4️⃣ Generate new access token
-	accessToken, err := utils.GenerateJWT(map[string]any{
-		"user_id": user.ID,
-		"role":    "user",
+	c.JSON(http.StatusOK, gin.H{
+		"message":             "OTP verified successfully",
+		"purpose":             models.OTPPurposePasswordReset,
+		"verification_ticket": ticket,
+		"expires_in":          int(otp.TicketTTL.Seconds()),
 	})
+}
+
+// POST /user/resetPassword
+//
+// Takes the ticket from forgotPassword/verify, sets the new
+// password and logs out every session of the account.
+func ResetPassword(c *gin.Context) {
+	type ResetPasswordRequest struct {
+		Phone              string `json:"phone" binding:"required"`
+		VerificationTicket string `json:"verificationTicket" binding:"required"`
+		NewPassword        string `json:"newPassword" binding:"required"`
+	}
+
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	phone, err := utils.NormalizePhone(req.Phone)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create access token"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid phone number"})
 		return
 	}
+	req.Phone = phone
 
-	// 5️⃣ Rotate refresh token
-	newRefreshToken, err := utils.GenerateRefreshToken()
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate refresh token"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
 		return
 	}
 
-	expiry := time.Now().Add(30 * 24 * time.Hour)
-	if err := dataprovider.UpdateUserRefreshToken(user.ID, newRefreshToken, expiry); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update refresh token"})
+	err = otp.Redeem("user", req.Phone, models.OTPPurposePasswordReset, req.VerificationTicket, func(tx *gorm.DB) error {
+		return dataprovider.ResetUserPassword(tx, req.Phone, string(hashedPassword))
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, otp.ErrInvalidPhone):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid phone number"})
+		case errors.Is(err, otp.ErrInvalidTicket), errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired verification ticket"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		}
 		return
 	}
 
-	c.SetCookie("refresh_token", newRefreshToken, int((30 * 24 * time.Hour).Seconds()), "/", "", true, true)
+	c.SetCookie("refresh_token", "", -1, "/", "", false, true)
+	c.JSON(http.StatusOK, gin.H{"message": "Password reset successfully"})
+}
 
-	c.JSON(http.StatusOK, gin.H{
-		"access_token": accessToken,
-	})
+func RefreshTokenUser(c *gin.Context) {
+	auth_controller.RefreshSession(c, "user")
 }
 
 func ClassDetails(c *gin.Context) {
-	classID, exists := c.Get("classID")
+	classID, exists := middlewares.GetClassID(c)
 	if !exists {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "classID not provided"})
 		return
 	}
 
-	classIDUint, ok := classID.(uint)
-	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid classID type"})
-		return
-	}
-
 	// Fetch class details from the database
-	class, err := dataprovider.GetClassByID(classIDUint)
+	class, err := dataprovider.GetClassByID(classID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Class not found"})
@@ -598,27 +941,26 @@ func ClassDetails(c *gin.Context) {
 		return
 	}
 
+	// Codes are handed out by the class's admins; a student who leaked one
+	// shouldn't get its replacement after a rotation
+	class.ClassCode = ""
+
 	c.JSON(http.StatusOK, gin.H{"class": class})
 }
 
 func QuickSummary(c *gin.Context) {
-	userID, exists := c.Get("userId")
+	principal, exists := middlewares.GetPrincipal(c)
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
-	classID, exists := c.Get("classID")
+	classID, exists := middlewares.GetClassID(c)
 	if !exists {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "classID not provided"})
 		return
 	}
-	classIDFloat, ok := classID.(uint)
-	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid classID type"})
-		return
-	}
 
-	quickSummary, err := dataprovider.GetUserQuickSummary(uint(userID.(float64)), classIDFloat, "user")
+	quickSummary, err := dataprovider.GetUserQuickSummary(principal.ID, classID, "user")
 	if err != nil {
 		println(err.Error())
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch quick summary"})
@@ -632,25 +974,19 @@ func QuickSummary(c *gin.Context) {
 }
 
 func Calendar(c *gin.Context) {
-	userID, exists := c.Get("userId")
+	principal, exists := middlewares.GetPrincipal(c)
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
-	classID, exists := c.Get("classID")
+	classID, exists := middlewares.GetClassID(c)
 	if !exists {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "classID not provided"})
 		return
 	}
 
-	classIDFloat, ok := classID.(uint)
-	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid classID type"})
-		return
-	}
-
 	// Get all attendance records for this user in this class
-	attendanceRecords, err := dataprovider.GetUserCalendar(uint(userID.(float64)), classIDFloat, "user")
+	attendanceRecords, err := dataprovider.GetUserCalendar(principal.ID, classID, "user")
 	if err != nil {
 		println(err.Error())
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch attendance records"})
@@ -668,52 +1004,30 @@ func Calendar(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"class_id": classID,
-		"user_id":  userID,
+		"user_id":  principal.ID,
 		"calendar": calendar,
 	})
 
 }
 
 func LogOutUser(c *gin.Context) {
-	// 1️⃣ Extract refresh token from cookie
-	refreshToken, err := c.Cookie("refresh_token")
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing refresh token"})
-		return
-	}
-
-	// 2️⃣ Validate and revoke the refresh token
-	if err := dataprovider.RevokeUserRefreshToken(refreshToken); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke refresh token"})
-		return
-	}
-
-	// 3️⃣ Clear the refresh token cookie
-	c.SetCookie("refresh_token", "", -1, "/", "", false, true)
-
-	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+	auth_controller.EndSession(c)
 }
 
 func Streak(c *gin.Context) {
-	classID, exists := c.Get("classID")
+	classID, exists := middlewares.GetClassID(c)
 	if !exists {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing classID"})
 		return
 	}
 
-	userIDVal, exists := c.Get("userId")
+	principal, exists := middlewares.GetPrincipal(c)
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
 
-	classIDFloat, ok := classID.(uint)
-	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid classID type"})
-		return
-	}
-
-	curr, best, err := dataprovider.GetUserStreak(uint(userIDVal.(float64)), classIDFloat, "user")
+	curr, best, err := dataprovider.GetUserStreak(principal.ID, classID, "user")
 	if err != nil {
 		println(err.Error())
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch streak data"})
@@ -0,0 +1,149 @@
+package dataprovider
+
+import (
+	"errors"
+	"time"
+
+	"github.com/hyphenXY/Streak-App/internal/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrPhoneTaken = errors.New("phone already registered to another account")
+	ErrEmailTaken = errors.New("email already registered to another account")
+)
+
+// accountModel returns the table of role, for the queries shared by users,
+// admins and roots.
+func accountModel(role string) interface{} {
+	switch role {
+	case "admin":
+		return &models.Admin{}
+	case "root":
+		return &models.Root{}
+	}
+	return &models.User{}
+}
+
+// GetPasswordHash returns the bcrypt hash of an account's password.
+func GetPasswordHash(subjectID uint, role string) (string, error) {
+	var hashes []string
+	if err := DB.Model(accountModel(role)).Where("id = ?", subjectID).Pluck("password", &hashes).Error; err != nil {
+		return "", err
+	}
+	if len(hashes) == 0 {
+		return "", gorm.ErrRecordNotFound
+	}
+	return hashes[0], nil
+}
+
+// ChangePassword stores a new password hash and revokes every session of the
+// account except keepSessionID. It returns how many sessions were revoked.
+func ChangePassword(subjectID uint, role string, passwordHash string, keepSessionID uint) (int64, error) {
+	var revoked int64
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(accountModel(role)).Where("id = ?", subjectID).Update("password", passwordHash)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		var err error
+		revoked, err = revokeOtherSessions(tx, subjectID, role, keepSessionID)
+		return err
+	})
+	return revoked, err
+}
+
+// PhoneTaken reports whether another account of role uses phone.
+func PhoneTaken(tx *gorm.DB, role string, phone string, exceptID uint) (bool, error) {
+	var count int64
+	err := tx.Model(accountModel(role)).
+		Where("phone = ? AND id <> ?", phone, exceptID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// ChangePhone moves an account to a new phone using tx, failing with
+// ErrPhoneTaken if another account of the same role already has it. The
+// unique phone index catches two accounts racing for the same number.
+func ChangePhone(tx *gorm.DB, subjectID uint, role string, phone string) error {
+	taken, err := PhoneTaken(tx, role, phone, subjectID)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrPhoneTaken
+	}
+
+	result := tx.Model(accountModel(role)).Where("id = ?", subjectID).Update("phone", phone)
+	if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+		return ErrPhoneTaken
+	}
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	if role == "user" {
+		// The new phone was just verified by OTP
+		_, err := ClaimPlaceholders(tx, subjectID, phone, "")
+		return err
+	}
+	return nil
+}
+
+// updateProfile applies the non-empty first_name, last_name and email of req
+// to an account of role. A changed email must be verified again before codes
+// go to it, so its verification and the email OTP channel are reset in the
+// same transaction. Fails with ErrEmailTaken if another account of the role
+// uses the email.
+func updateProfile(role string, req map[string]interface{}, subjectID uint) error {
+	updates := map[string]interface{}{"updated_at": time.Now()}
+	if firstName, _ := req["first_name"].(string); firstName != "" {
+		updates["first_name"] = firstName
+	}
+	if lastName, _ := req["last_name"].(string); lastName != "" {
+		updates["last_name"] = lastName
+	}
+	email, _ := req["email"].(string)
+
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if email != "" {
+			var count int64
+			if err := tx.Model(accountModel(role)).
+				Where("email = ? AND id <> ?", email, subjectID).
+				Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return ErrEmailTaken
+			}
+
+			// Roots don't verify their email or get codes sent to it
+			if role != "root" {
+				if err := tx.Model(accountModel(role)).
+					Where("id = ? AND email <> ?", subjectID, email).
+					Updates(map[string]interface{}{
+						"email_verified_at": nil,
+						"otp_channel":       models.OTPChannelSMS,
+					}).Error; err != nil {
+					return err
+				}
+			}
+			updates["email"] = email
+		}
+
+		result := tx.Model(accountModel(role)).Where("id = ?", subjectID).Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
@@ -1,80 +1,251 @@
 package dataprovider
 
 import (
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/hyphenXY/Streak-App/internal/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 func CreateAdmin(admin *models.Admin) error {
 	return DB.Create(admin).Error
 }
 
-func GetClassesByAdmin(adminID uint, classes *[]models.Classes) error {
-	return DB.Where("created_by_admin_id = ?", adminID).Find(classes).Error
-}
-
-func UpdateAdminRefreshToken(adminID uint, refreshToken string, refreshTokenExpiry time.Time) error {
-	result := DB.Model(&models.Admin{}).
-		Where("id = ?", adminID).
-		Updates(map[string]interface{}{
-			"RefreshToken":       refreshToken,
-			"RefreshTokenExpiry": refreshTokenExpiry,
-		})
-	return result.Error
+// GetClassesByAdmin returns every class the admin is accepted staff of.
+// Archived classes are left out unless includeArchived is set.
+func GetClassesByAdmin(adminID uint, includeArchived bool, classes *[]models.Classes) error {
+	query := DB.Joins("JOIN class_staffs ON class_staffs.class_id = classes.id").
+		Where("class_staffs.admin_id = ? AND class_staffs.accepted_at IS NOT NULL", adminID)
+	if !includeArchived {
+		query = query.Where("classes.archived_at IS NULL")
+	}
+	return query.Find(classes).Error
 }
 
 func AdminNameById(adminID uint, admin *models.Admin) error {
 	return DB.Where("id = ?", adminID).First(admin).Error
 }
 
-func RevokeAdminRefreshToken(refreshToken string) error {
-	result := DB.Model(&models.Admin{}).
-		Where("refresh_token = ?", refreshToken).
-		Updates(map[string]interface{}{
-			"refresh_token":        nil,
-			"refresh_token_expiry": nil,
-		})
-	return result.Error
-}
-
 func GetAdminProfile(adminID uint) (*models.Admin, error) {
 	admin := &models.Admin{}
 	DB.Where("id = ?", adminID).First(admin)
 	return admin, nil
 }
 
+// UpdateAdminProfile updates the name and email of an admin. See
+// updateProfile.
 func UpdateAdminProfile(req map[string]interface{}, userId uint) error {
+	return updateProfile("admin", req, userId)
+}
+
+var ErrAdminNotFound = errors.New("admin not found")
+
+// Class handling policies for DeleteAdmin.
+const (
+	AdminClassesDelete   = "delete"
+	AdminClassesTransfer = "transfer"
+)
 
-	updates := map[string]interface{}{}
+// ListAdmins returns one page of admins ordered by id, along with the total
+// admin count and how many classes each admin on the page owns.
+func ListAdmins(page, pageSize int) ([]models.Admin, int64, map[uint]int64, error) {
+	var total int64
+	if err := DB.Model(&models.Admin{}).Count(&total).Error; err != nil {
+		return nil, 0, nil, err
+	}
 
-	if req["first_name"] != "" {
-		updates["FirstName"] = req["first_name"]
+	var admins []models.Admin
+	if err := DB.Order("id ASC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&admins).Error; err != nil {
+		return nil, 0, nil, err
 	}
-	if req["last_name"] != "" {
-		updates["LastName"] = req["last_name"]
+
+	classCounts := make(map[uint]int64, len(admins))
+	if len(admins) == 0 {
+		return admins, total, classCounts, nil
 	}
-	if req["Email"] != "" {
-		updates["Email"] = req["Email"]
+
+	ids := make([]uint, 0, len(admins))
+	for _, a := range admins {
+		ids = append(ids, a.ID)
 	}
 
-	// Always update UpdatedAt
-	updates["UpdatedAt"] = time.Now()
+	var rows []struct {
+		CreatedByAdminId uint
+		Count            int64
+	}
+	if err := DB.Model(&models.Classes{}).
+		Select("created_by_admin_id, COUNT(*) AS count").
+		Where("created_by_admin_id IN ?", ids).
+		Group("created_by_admin_id").
+		Scan(&rows).Error; err != nil {
+		return nil, 0, nil, err
+	}
+	for _, r := range rows {
+		classCounts[r.CreatedByAdminId] = r.Count
+	}
+
+	return admins, total, classCounts, nil
+}
 
-	if len(updates) == 0 {
+func CountClassesByAdmin(adminID uint) (int64, error) {
+	var count int64
+	err := DB.Model(&models.Classes{}).Where("created_by_admin_id = ?", adminID).Count(&count).Error
+	return count, err
+}
+
+// SetAdminSuspended suspends or reinstates an admin. Suspending also revokes
+// the admin's sessions so none of them can be renewed.
+func SetAdminSuspended(adminID uint, suspended bool) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		var suspendedAt interface{}
+		if suspended {
+			suspendedAt = time.Now()
+		}
+
+		result := tx.Model(&models.Admin{}).Where("id = ?", adminID).Update("suspended_at", suspendedAt)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrAdminNotFound
+		}
+		if suspended {
+			return RevokeAllSessions(tx, adminID, "admin")
+		}
 		return nil
+	})
+}
+
+func IsAdminSuspended(adminID uint) (bool, error) {
+	var admin models.Admin
+	if err := DB.Select("id", "suspended_at").Where("id = ?", adminID).First(&admin).Error; err != nil {
+		return false, err
 	}
+	return admin.SuspendedAt != nil, nil
+}
 
-	result := DB.Model(&models.Admin{}).
-		Where("id = ?", userId).
-		Updates(updates)
+// DeleteAdmin removes an admin together with the classes they own. Depending
+// on classPolicy, owned classes are either deleted with their enrollments and
+// attendance, or handed over to transferTo; either way the change goes into
+// each class's audit trail under the root actorID. It returns the number of
+// classes affected.
+func DeleteAdmin(adminID uint, classPolicy string, transferTo uint, actorID uint) (int64, error) {
+	var affected int64
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		var admin models.Admin
+		if err := tx.Where("id = ?", adminID).First(&admin).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrAdminNotFound
+			}
+			return err
+		}
+
+		var classes []models.Classes
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("created_by_admin_id = ?", adminID).
+			Find(&classes).Error; err != nil {
+			return err
+		}
+		affected = int64(len(classes))
+
+		for i := range classes {
+			class := &classes[i]
+			switch classPolicy {
+			case AdminClassesTransfer:
+				if err := tx.Model(class).Update("created_by_admin_id", transferTo).Error; err != nil {
+					return err
+				}
+				// The new owner may already be on the staff
+				if err := tx.Where("class_id = ? AND admin_id = ?", class.ID, transferTo).
+					Delete(&models.ClassStaff{}).Error; err != nil {
+					return err
+				}
+				if err := tx.Model(&models.ClassStaff{}).
+					Where("class_id = ? AND admin_id = ?", class.ID, adminID).
+					Updates(map[string]interface{}{"admin_id": transferTo, "accepted_at": time.Now()}).Error; err != nil {
+					return err
+				}
+				if err := recordClassEvent(tx, class.ID, models.ClassEventOwnerReassigned, actorID, "root",
+					fmt.Sprintf("owner changed from admin %d to admin %d on deleting the owner", adminID, transferTo)); err != nil {
+					return err
+				}
+			case AdminClassesDelete:
+				if err := deleteClassTx(tx, class, actorID, "root"); err != nil {
+					return err
+				}
+			default:
+				return errors.New("unknown class policy")
+			}
+		}
+
+		if err := tx.Where("subject_id = ? AND role = ?", adminID, "admin").Delete(&models.Session{}).Error; err != nil {
+			return err
+		}
+		// Second factors and verification codes die with the account
+		for _, model := range []interface{}{&models.TwoFactor{}, &models.RecoveryCode{}, &models.TwoFactorChallenge{}, &models.EmailVerification{}} {
+			if err := tx.Where("subject_id = ? AND role = ?", adminID, "admin").Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		// Pending handovers to or of this admin can't complete any more
+		if err := tx.Model(&models.ClassTransfer{}).
+			Where("status = ? AND (to_admin_id = ? OR from_admin_id = ?)", models.ClassTransferPending, adminID, adminID).
+			Updates(map[string]interface{}{"status": models.ClassTransferCancelled, "responded_at": time.Now()}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("admin_id = ?", adminID).Delete(&models.ClassStaff{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("admin_id = ?", adminID).Delete(&models.PersonalAccessToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&admin).Error
+	})
+	return affected, err
+}
+
+func AdminExistsByPhone(phone string) (bool, error) {
+	var count int64
+	err := DB.Model(&models.Admin{}).Where("phone = ?", phone).Count(&count).Error
+	return count > 0, err
+}
+
+// GetAdminByPhone loads the admin registered to phone.
+func GetAdminByPhone(tx *gorm.DB, phone string) (*models.Admin, error) {
+	var admin models.Admin
+	if err := tx.Where("phone = ?", phone).First(&admin).Error; err != nil {
+		return nil, err
+	}
+	return &admin, nil
+}
+
+// GetAdminByUserName loads the admin with the given username.
+func GetAdminByUserName(userName string) (*models.Admin, error) {
+	var admin models.Admin
+	if err := DB.Where("user_name = ?", userName).First(&admin).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAdminNotFound
+		}
+		return nil, err
+	}
+	return &admin, nil
+}
 
-	if result.Error != nil {
-		return result.Error
+// ResetAdminPassword sets a new password hash on the admin registered to
+// phone and revokes all of their sessions.
+func ResetAdminPassword(tx *gorm.DB, phone string, passwordHash string) error {
+	var admin models.Admin
+	if err := tx.Where("phone = ?", phone).First(&admin).Error; err != nil {
+		return err
 	}
-	if result.RowsAffected == 0 {
-		return gorm.ErrRecordNotFound
+	if err := tx.Model(&admin).Update("password", passwordHash).Error; err != nil {
+		return err
 	}
-	return nil
+	return RevokeAllSessions(tx, admin.ID, "admin")
 }
@@ -0,0 +1,156 @@
+package dataprovider
+
+import (
+	"time"
+
+	"github.com/hyphenXY/Streak-App/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AuthEventFilter narrows ListAuthEvents. Zero fields match everything.
+type AuthEventFilter struct {
+	Role      string
+	SubjectID *uint
+	Type      string
+	Since     *time.Time
+}
+
+// SignInFailureStats summarises recent failed sign-ins, as used by the
+// sign-in lockout.
+type SignInFailureStats struct {
+	// Failures of the lockout key since the account's last success.
+	AccountFailures    int64
+	LastAccountFailure *time.Time
+	IPFailures         int64
+	OldestIPFailure    *time.Time
+}
+
+func CreateAuthEvent(event *models.AuthEvent) error {
+	return DB.Create(event).Error
+}
+
+// BeginSignInAttempt locks the lockout key of attempt, counts its failures
+// since since and asks allow whether the attempt may go ahead. If so, the
+// attempt is recorded as a failure before the lock is released, so the next
+// attempt on the key already counts it; the caller updates or deletes it once
+// it knows the outcome.
+func BeginSignInAttempt(attempt *models.AuthEvent, since time.Time, allow func(SignInFailureStats) bool) (bool, error) {
+	allowed := false
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		lock := models.SignInLock{Role: attempt.Role, LockoutKey: attempt.LockoutKey}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&lock).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("role = ? AND lockout_key = ?", lock.Role, lock.LockoutKey).
+			First(&lock).Error; err != nil {
+			return err
+		}
+
+		stats, err := signInFailureStats(tx, attempt.Role, attempt.LockoutKey, attempt.SubjectID, attempt.IP, since)
+		if err != nil {
+			return err
+		}
+		if !allow(stats) {
+			return nil
+		}
+		allowed = true
+		return tx.Create(attempt).Error
+	})
+	return allowed, err
+}
+
+// UpdateSignInAttempt records why a begun sign-in attempt failed.
+func UpdateSignInAttempt(eventID uint, details string) error {
+	return DB.Model(&models.AuthEvent{}).Where("id = ?", eventID).Update("details", details).Error
+}
+
+// DeleteSignInAttempt drops a begun sign-in attempt whose credentials turned
+// out to be right, so it doesn't count as a failure.
+func DeleteSignInAttempt(eventID uint) error {
+	return DB.Where("id = ? AND type = ?", eventID, models.AuthEventSignInFailure).Delete(&models.AuthEvent{}).Error
+}
+
+// signInFailureStats counts failed sign-ins since since, for the lockout key
+// of role and for ip. Key failures before the most recent successful sign-in
+// of subjectID are not counted; subjectID 0 has none.
+func signInFailureStats(tx *gorm.DB, role string, key string, subjectID uint, ip string, since time.Time) (SignInFailureStats, error) {
+	var stats SignInFailureStats
+
+	if key != "" {
+		accountSince := since
+		if subjectID != 0 {
+			var lastSuccess []time.Time
+			if err := tx.Model(&models.AuthEvent{}).
+				Where("role = ? AND subject_id = ? AND type = ? AND created_at >= ?", role, subjectID, models.AuthEventSignInSuccess, since).
+				Order("created_at DESC").Limit(1).
+				Pluck("created_at", &lastSuccess).Error; err != nil {
+				return stats, err
+			}
+			if len(lastSuccess) > 0 {
+				accountSince = lastSuccess[0]
+			}
+		}
+
+		var row struct {
+			Count int64
+			Last  *time.Time
+		}
+		if err := tx.Model(&models.AuthEvent{}).
+			Select("COUNT(*) AS count, MAX(created_at) AS last").
+			Where("role = ? AND lockout_key = ? AND type = ? AND created_at > ?", role, key, models.AuthEventSignInFailure, accountSince).
+			Scan(&row).Error; err != nil {
+			return stats, err
+		}
+		stats.AccountFailures = row.Count
+		stats.LastAccountFailure = row.Last
+	}
+
+	if ip != "" {
+		var row struct {
+			Count  int64
+			Oldest *time.Time
+		}
+		if err := tx.Model(&models.AuthEvent{}).
+			Select("COUNT(*) AS count, MIN(created_at) AS oldest").
+			Where("ip = ? AND type = ? AND created_at >= ?", ip, models.AuthEventSignInFailure, since).
+			Scan(&row).Error; err != nil {
+			return stats, err
+		}
+		stats.IPFailures = row.Count
+		stats.OldestIPFailure = row.Oldest
+	}
+
+	return stats, nil
+}
+
+// ListAuthEvents returns one page of events matching filter, newest first,
+// along with the total number of matches.
+func ListAuthEvents(filter AuthEventFilter, page int, pageSize int) ([]models.AuthEvent, int64, error) {
+	query := DB.Model(&models.AuthEvent{})
+	if filter.Role != "" {
+		query = query.Where("role = ?", filter.Role)
+	}
+	if filter.SubjectID != nil {
+		query = query.Where("subject_id = ?", *filter.SubjectID)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.Since != nil {
+		query = query.Where("created_at >= ?", *filter.Since)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var events []models.AuthEvent
+	err := query.Order("created_at DESC, id DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&events).Error
+	return events, total, err
+}
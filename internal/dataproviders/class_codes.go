@@ -0,0 +1,250 @@
+package dataprovider
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hyphenXY/Streak-App/internal/models"
+	"github.com/hyphenXY/Streak-App/internal/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// classCodeAttempts bounds the retries when a generated code is already taken.
+const classCodeAttempts = 5
+
+var (
+	ErrClassCodeNotFound = errors.New("class code not found")
+	ErrClassCodeInactive = errors.New("class code expired, used up or revoked")
+	ErrAlreadyEnrolled   = errors.New("user already enrolled")
+)
+
+// ClassCodeOptions are the limits put on a new class code.
+type ClassCodeOptions struct {
+	Label     string
+	ExpiresAt *time.Time
+	MaxUses   *int
+}
+
+// withUniqueClassCode runs create with fresh codes until one doesn't collide
+// with an existing code, each attempt in its own transaction.
+func withUniqueClassCode(create func(tx *gorm.DB, code string) error) error {
+	for attempt := 0; attempt < classCodeAttempts; attempt++ {
+		code, err := utils.GenerateClassCode()
+		if err != nil {
+			return err
+		}
+		err = DB.Transaction(func(tx *gorm.DB) error {
+			return create(tx, code)
+		})
+		if !errors.Is(err, gorm.ErrDuplicatedKey) {
+			return err
+		}
+	}
+	return fmt.Errorf("no free class code after %d attempts", classCodeAttempts)
+}
+
+func createClassCode(tx *gorm.DB, classID uint, code string, opts ClassCodeOptions, adminID uint) (*models.ClassCode, error) {
+	classCode := models.ClassCode{
+		ClassID:          classID,
+		Code:             code,
+		Label:            opts.Label,
+		ExpiresAt:        opts.ExpiresAt,
+		MaxUses:          opts.MaxUses,
+		CreatedByAdminID: adminID,
+	}
+	if err := tx.Create(&classCode).Error; err != nil {
+		return nil, err
+	}
+	return &classCode, nil
+}
+
+func ListClassCodes(classID uint, includeRevoked bool) ([]models.ClassCode, error) {
+	var codes []models.ClassCode
+	query := DB.Where("class_id = ?", classID)
+	if !includeRevoked {
+		query = query.Where("revoked_at IS NULL")
+	}
+	err := query.Order("id DESC").Find(&codes).Error
+	return codes, err
+}
+
+// CreateClassCode issues an additional code for the class.
+func CreateClassCode(classID uint, opts ClassCodeOptions, adminID uint) (*models.ClassCode, error) {
+	var created *models.ClassCode
+	err := withUniqueClassCode(func(tx *gorm.DB, code string) error {
+		classCode, err := createClassCode(tx, classID, code, opts, adminID)
+		if err != nil {
+			return err
+		}
+		created = classCode
+		return recordClassEvent(tx, classID, models.ClassEventCodeCreated, adminID, "admin",
+			fmt.Sprintf("code %d %q", classCode.ID, classCode.Label))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+func lockClassCode(tx *gorm.DB, classID uint, codeID uint) (*models.ClassCode, error) {
+	var classCode models.ClassCode
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND class_id = ? AND revoked_at IS NULL", codeID, classID).
+		First(&classCode).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrClassCodeNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &classCode, nil
+}
+
+// RotateClassCode revokes a code and issues a new one with the same label
+// and limits and a fresh use count. The new code expires at expiresAt, or
+// when the old one did if that is nil; rotating into a code that is already
+// expired fails with ErrClassCodeInactive. If the old code was the class's
+// main code the class is pointed at the new one.
+func RotateClassCode(classID uint, codeID uint, expiresAt *time.Time, adminID uint) (*models.ClassCode, error) {
+	var rotated *models.ClassCode
+	err := withUniqueClassCode(func(tx *gorm.DB, code string) error {
+		old, err := lockClassCode(tx, classID, codeID)
+		if err != nil {
+			return err
+		}
+		if expiresAt == nil {
+			expiresAt = old.ExpiresAt
+		}
+		if expiresAt != nil && !expiresAt.After(time.Now()) {
+			return ErrClassCodeInactive
+		}
+		if err := tx.Model(old).Update("revoked_at", time.Now()).Error; err != nil {
+			return err
+		}
+
+		classCode, err := createClassCode(tx, classID, code, ClassCodeOptions{
+			Label:     old.Label,
+			ExpiresAt: expiresAt,
+			MaxUses:   old.MaxUses,
+		}, adminID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Classes{}).
+			Where("id = ? AND class_code = ?", classID, old.Code).
+			Update("class_code", code).Error; err != nil {
+			return err
+		}
+
+		rotated = classCode
+		return recordClassEvent(tx, classID, models.ClassEventCodeRotated, adminID, "admin",
+			fmt.Sprintf("code %d replaced by %d", old.ID, classCode.ID))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rotated, nil
+}
+
+func RevokeClassCode(classID uint, codeID uint, adminID uint) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		classCode, err := lockClassCode(tx, classID, codeID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(classCode).Update("revoked_at", time.Now()).Error; err != nil {
+			return err
+		}
+		if err := repointClassCodeMirror(tx, classID, classCode.Code); err != nil {
+			return err
+		}
+		return recordClassEvent(tx, classID, models.ClassEventCodeRevoked, adminID, "admin",
+			fmt.Sprintf("code %d", classCode.ID))
+	})
+}
+
+// repointClassCodeMirror moves classes.class_code off a revoked code, to the
+// newest code of the class that still works, or to NULL if none does.
+func repointClassCodeMirror(tx *gorm.DB, classID uint, revokedCode string) error {
+	var codes []models.ClassCode
+	if err := tx.Where("class_id = ? AND revoked_at IS NULL", classID).
+		Order("id DESC").
+		Find(&codes).Error; err != nil {
+		return err
+	}
+
+	var replacement interface{} = gorm.Expr("NULL")
+	now := time.Now()
+	for _, code := range codes {
+		if code.Active(now) {
+			replacement = code.Code
+			break
+		}
+	}
+	return tx.Model(&models.Classes{}).
+		Where("id = ? AND class_code = ?", classID, revokedCode).
+		Update("class_code", replacement).Error
+}
+
+// EnrollWithClassCode enrolls the user in the class the code belongs to and
+// counts the use. The code row is locked so concurrent enrollments can't go
+// past MaxUses. When the class requires approval a pending request is made
+// instead, and pending is true.
+func EnrollWithClassCode(userID uint, code string) (classID uint, pending bool, err error) {
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		var classCode models.ClassCode
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("code = ?", code).
+			First(&classCode).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrClassCodeNotFound
+			}
+			return err
+		}
+		if !classCode.Active(time.Now()) {
+			return ErrClassCodeInactive
+		}
+		classID = classCode.ClassID
+
+		var err error
+		if pending, err = enrollOrRequest(tx, userID, classID, classCode.ID); err != nil {
+			return err
+		}
+		return tx.Model(&classCode).Update("uses", gorm.Expr("uses + 1")).Error
+	})
+	return classID, pending, err
+}
+
+// enrollOrRequest enrolls the user in an active class, or files a pending
+// request when the class requires approval. classCodeID is 0 when the user
+// didn't come in through a class code.
+func enrollOrRequest(tx *gorm.DB, userID uint, classID uint, classCodeID uint) (pending bool, err error) {
+	var class models.Classes
+	if err := tx.Select("id", "archived_at", "require_approval").Where("id = ?", classID).First(&class).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, ErrClassNotFound
+		}
+		return false, err
+	}
+	if class.ArchivedAt != nil {
+		return false, ErrClassArchived
+	}
+
+	var enrolled int64
+	if err := tx.Model(&models.User_Classes{}).
+		Where("user_id = ? AND class_id = ? AND inactive_at IS NULL", userID, classID).
+		Count(&enrolled).Error; err != nil {
+		return false, err
+	}
+	if enrolled > 0 {
+		return false, ErrAlreadyEnrolled
+	}
+
+	if class.RequireApproval {
+		return true, createEnrollmentRequest(tx, classID, userID, classCodeID)
+	}
+	_, err = enrollUser(tx, userID, classID)
+	return false, err
+}
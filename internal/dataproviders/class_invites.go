@@ -0,0 +1,80 @@
+package dataprovider
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hyphenXY/Streak-App/internal/models"
+	"gorm.io/gorm"
+)
+
+// ErrClassInviteRevoked means the invite was revoked, or the admin who issued
+// it can no longer manage the class roster, which voids every invite they
+// handed out.
+var ErrClassInviteRevoked = errors.New("class invite no longer valid")
+
+// RecordClassInviteIssued notes an invite in the class's audit trail. Invite
+// tokens aren't stored, so this is the only record of them.
+func RecordClassInviteIssued(classID uint, adminID uint, expiresAt time.Time) error {
+	return recordClassEvent(DB, classID, models.ClassEventInviteIssued, adminID, "admin",
+		fmt.Sprintf("expires %s", expiresAt.UTC().Format(time.RFC3339)))
+}
+
+// GetClassInviteEpoch returns the invite epoch new invites to the class are
+// stamped with.
+func GetClassInviteEpoch(classID uint) (uint, error) {
+	var class models.Classes
+	if err := DB.Select("id", "invite_epoch").First(&class, classID).Error; err != nil {
+		return 0, err
+	}
+	return class.InviteEpoch, nil
+}
+
+// RevokeClassInvites voids every invite to the class issued so far by moving
+// the class to a new invite epoch.
+func RevokeClassInvites(classID uint, adminID uint) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Classes{}).
+			Where("id = ?", classID).
+			Update("invite_epoch", gorm.Expr("invite_epoch + 1")).Error; err != nil {
+			return err
+		}
+		return recordClassEvent(tx, classID, models.ClassEventInvitesRevoked, adminID, "admin", "")
+	})
+}
+
+// EnrollWithClassInvite enrolls the user in the class of a verified invite
+// token, or files a pending request when the class requires approval.
+func EnrollWithClassInvite(userID uint, classID uint, issuedBy uint, epoch uint) (pending bool, err error) {
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		var class models.Classes
+		err := tx.Select("id", "invite_epoch").First(&class, classID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrClassNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if class.InviteEpoch != epoch {
+			return ErrClassInviteRevoked
+		}
+
+		var staff models.ClassStaff
+		err = tx.Where("admin_id = ? AND class_id = ? AND accepted_at IS NOT NULL", issuedBy, classID).
+			First(&staff).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrClassInviteRevoked
+		}
+		if err != nil {
+			return err
+		}
+		if !models.StaffRoleHas(staff.Role, models.PermManageRoster) {
+			return ErrClassInviteRevoked
+		}
+
+		pending, err = enrollOrRequest(tx, userID, classID, 0)
+		return err
+	})
+	return pending, err
+}
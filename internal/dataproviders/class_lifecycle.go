@@ -0,0 +1,149 @@
+package dataprovider
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hyphenXY/Streak-App/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrClassArchived    = errors.New("class is archived")
+	ErrClassNotArchived = errors.New("class is not archived")
+)
+
+// IsClassArchived reports whether the class has been archived.
+func IsClassArchived(classID uint) (bool, error) {
+	var class models.Classes
+	if err := DB.Select("id", "archived_at").Where("id = ?", classID).First(&class).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, ErrClassNotFound
+		}
+		return false, err
+	}
+	return class.ArchivedAt != nil, nil
+}
+
+// lockClass loads the class for update.
+func lockClass(tx *gorm.DB, classID uint) (*models.Classes, error) {
+	var class models.Classes
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", classID).First(&class).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrClassNotFound
+		}
+		return nil, err
+	}
+	return &class, nil
+}
+
+// UpdateClass applies updates (column name to value) to an active class.
+func UpdateClass(classID uint, updates map[string]interface{}, actorID uint, actorRole string) (*models.Classes, error) {
+	var updated *models.Classes
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		class, err := lockClass(tx, classID)
+		if err != nil {
+			return err
+		}
+		if class.ArchivedAt != nil {
+			return ErrClassArchived
+		}
+		if len(updates) > 0 {
+			if err := tx.Model(class).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+
+		fields := make([]string, 0, len(updates))
+		for field := range updates {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		updated = class
+		return recordClassEvent(tx, classID, models.ClassEventUpdated, actorID, actorRole, "changed "+strings.Join(fields, ", "))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// SetClassArchived archives or restores a class.
+func SetClassArchived(classID uint, archived bool, actorID uint, actorRole string) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		class, err := lockClass(tx, classID)
+		if err != nil {
+			return err
+		}
+		if archived && class.ArchivedAt != nil {
+			return ErrClassArchived
+		}
+		if !archived && class.ArchivedAt == nil {
+			return ErrClassNotArchived
+		}
+
+		var archivedAt interface{}
+		eventType := models.ClassEventRestored
+		if archived {
+			archivedAt = time.Now()
+			eventType = models.ClassEventArchived
+		}
+		if err := tx.Model(class).Update("archived_at", archivedAt).Error; err != nil {
+			return err
+		}
+		return recordClassEvent(tx, classID, eventType, actorID, actorRole, "")
+	})
+}
+
+// DeleteClass permanently removes an archived class with its enrollments,
+// attendance and staff. The class audit trail is kept.
+func DeleteClass(classID uint, actorID uint, actorRole string) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		class, err := lockClass(tx, classID)
+		if err != nil {
+			return err
+		}
+		if class.ArchivedAt == nil {
+			return ErrClassNotArchived
+		}
+
+		return deleteClassTx(tx, class, actorID, actorRole)
+	})
+}
+
+// deleteClassTx removes class with its enrollments, attendance, codes and
+// staff, cancels pending transfers of it and records the deletion in its
+// audit trail.
+func deleteClassTx(tx *gorm.DB, class *models.Classes, actorID uint, actorRole string) error {
+	if err := tx.Where("class_id = ?", class.ID).Delete(&models.Attendance{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("class_id = ?", class.ID).Delete(&models.User_Classes{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("class_id = ?", class.ID).Delete(&models.EnrollmentRequest{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("class_id = ?", class.ID).Delete(&models.PlaceholderEnrollment{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("class_id = ?", class.ID).Delete(&models.ClassCode{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("class_id = ?", class.ID).Delete(&models.ClassStaff{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.ClassTransfer{}).
+		Where("class_id = ? AND status = ?", class.ID, models.ClassTransferPending).
+		Updates(map[string]interface{}{"status": models.ClassTransferCancelled, "responded_at": time.Now()}).Error; err != nil {
+		return err
+	}
+	if err := tx.Delete(class).Error; err != nil {
+		return err
+	}
+	return recordClassEvent(tx, class.ID, models.ClassEventDeleted, actorID, actorRole, fmt.Sprintf("deleted class %q", class.Name))
+}
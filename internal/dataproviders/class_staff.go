@@ -0,0 +1,140 @@
+package dataprovider
+
+import (
+	"errors"
+	"time"
+
+	"github.com/hyphenXY/Streak-App/internal/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrStaffExists         = errors.New("admin is already on the class staff")
+	ErrStaffNotFound       = errors.New("staff member not found")
+	ErrStaffInviteNotFound = errors.New("staff invite not found")
+	ErrStaffOwnerRemoval   = errors.New("the class owner can't be removed")
+)
+
+// StaffMember is a class staff row together with the admin's name.
+type StaffMember struct {
+	AdminID          uint       `json:"admin_id"`
+	UserName         string     `json:"user_name"`
+	FirstName        string     `json:"first_name"`
+	LastName         string     `json:"last_name"`
+	Role             string     `json:"role"`
+	InvitedByAdminID uint       `json:"invited_by_admin_id"`
+	AcceptedAt       *time.Time `json:"accepted_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+// StaffInvite is a pending invite as seen by the invited admin.
+type StaffInvite struct {
+	ClassID          uint      `json:"class_id"`
+	ClassName        string    `json:"class_name"`
+	Role             string    `json:"role"`
+	InvitedByAdminID uint      `json:"invited_by_admin_id"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// GetStaffRole returns the admin's role in the class, or "" if they aren't
+// accepted staff there.
+func GetStaffRole(adminID uint, classID uint) (string, error) {
+	var staff models.ClassStaff
+	err := DB.Where("admin_id = ? AND class_id = ? AND accepted_at IS NOT NULL", adminID, classID).
+		First(&staff).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return staff.Role, nil
+}
+
+// ListClassStaff returns everyone on a class staff, invited ones included,
+// owner first.
+func ListClassStaff(classID uint) ([]StaffMember, error) {
+	var staff []StaffMember
+	err := DB.Model(&models.ClassStaff{}).
+		Select("class_staffs.admin_id, admins.user_name, admins.first_name, admins.last_name, class_staffs.role, class_staffs.invited_by_admin_id, class_staffs.accepted_at, class_staffs.created_at").
+		Joins("JOIN admins ON admins.id = class_staffs.admin_id").
+		Where("class_staffs.class_id = ?", classID).
+		Order("FIELD(class_staffs.role, 'owner', 'co_teacher', 'assistant'), class_staffs.id").
+		Scan(&staff).Error
+	return staff, err
+}
+
+// InviteStaff records a pending invite for adminID to join the class staff.
+func InviteStaff(classID uint, adminID uint, role string, invitedBy uint) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.ClassStaff{}).
+			Where("class_id = ? AND admin_id = ?", classID, adminID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrStaffExists
+		}
+		return tx.Create(&models.ClassStaff{
+			ClassID:          classID,
+			AdminID:          adminID,
+			Role:             role,
+			InvitedByAdminID: invitedBy,
+		}).Error
+	})
+}
+
+func ListStaffInvites(adminID uint) ([]StaffInvite, error) {
+	var invites []StaffInvite
+	err := DB.Model(&models.ClassStaff{}).
+		Select("class_staffs.class_id, classes.name AS class_name, class_staffs.role, class_staffs.invited_by_admin_id, class_staffs.created_at").
+		Joins("JOIN classes ON classes.id = class_staffs.class_id").
+		Where("class_staffs.admin_id = ? AND class_staffs.accepted_at IS NULL", adminID).
+		Order("class_staffs.id DESC").
+		Scan(&invites).Error
+	return invites, err
+}
+
+func AcceptStaffInvite(classID uint, adminID uint) error {
+	result := DB.Model(&models.ClassStaff{}).
+		Where("class_id = ? AND admin_id = ? AND accepted_at IS NULL", classID, adminID).
+		Update("accepted_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrStaffInviteNotFound
+	}
+	return nil
+}
+
+func DeclineStaffInvite(classID uint, adminID uint) error {
+	result := DB.Where("class_id = ? AND admin_id = ? AND accepted_at IS NULL", classID, adminID).
+		Delete(&models.ClassStaff{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrStaffInviteNotFound
+	}
+	return nil
+}
+
+// RemoveStaff takes an admin off the class staff, or withdraws their invite.
+// The owner has to hand the class over before they can go.
+func RemoveStaff(classID uint, adminID uint) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		var staff models.ClassStaff
+		if err := tx.Where("class_id = ? AND admin_id = ?", classID, adminID).First(&staff).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrStaffNotFound
+			}
+			return err
+		}
+		if staff.Role == models.StaffRoleOwner {
+			return ErrStaffOwnerRemoval
+		}
+		return tx.Delete(&staff).Error
+	})
+}
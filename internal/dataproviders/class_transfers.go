@@ -0,0 +1,215 @@
+package dataprovider
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hyphenXY/Streak-App/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrClassNotFound         = errors.New("class not found")
+	ErrClassTransferPending  = errors.New("class already has a pending transfer")
+	ErrClassTransferToOwner  = errors.New("admin already owns the class")
+	ErrClassTransferNotFound = errors.New("class transfer not found")
+	ErrClassTransferStale    = errors.New("class owner changed since the transfer was proposed")
+)
+
+// IncomingClassTransfer is a pending transfer as seen by the receiving admin.
+type IncomingClassTransfer struct {
+	ID             uint      `json:"id"`
+	ClassID        uint      `json:"class_id"`
+	ClassName      string    `json:"class_name"`
+	FromAdminID    uint      `json:"from_admin_id"`
+	ProposedByRole string    `json:"proposed_by_role"`
+	Note           string    `json:"note"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+func recordClassEvent(tx *gorm.DB, classID uint, eventType string, actorID uint, actorRole string, details string) error {
+	return tx.Create(&models.ClassEvent{
+		ClassID:   classID,
+		Type:      eventType,
+		ActorID:   actorID,
+		ActorRole: actorRole,
+		Details:   details,
+	}).Error
+}
+
+// ListClassEvents returns a class's audit trail, newest first.
+func ListClassEvents(classID uint) ([]models.ClassEvent, error) {
+	var events []models.ClassEvent
+	err := DB.Where("class_id = ?", classID).Order("id DESC").Find(&events).Error
+	return events, err
+}
+
+// ProposeClassTransfer offers the class to toAdminID. A class can only have
+// one pending transfer at a time.
+func ProposeClassTransfer(classID uint, toAdminID uint, actorID uint, actorRole string, note string) (*models.ClassTransfer, error) {
+	var transfer models.ClassTransfer
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		var class models.Classes
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", classID).First(&class).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrClassNotFound
+			}
+			return err
+		}
+		if class.CreatedByAdminId == toAdminID {
+			return ErrClassTransferToOwner
+		}
+
+		var pending int64
+		if err := tx.Model(&models.ClassTransfer{}).
+			Where("class_id = ? AND status = ?", classID, models.ClassTransferPending).
+			Count(&pending).Error; err != nil {
+			return err
+		}
+		if pending > 0 {
+			return ErrClassTransferPending
+		}
+
+		transfer = models.ClassTransfer{
+			ClassID:        classID,
+			FromAdminID:    class.CreatedByAdminId,
+			ToAdminID:      toAdminID,
+			ProposedByID:   actorID,
+			ProposedByRole: actorRole,
+			Note:           note,
+			Status:         models.ClassTransferPending,
+		}
+		if err := tx.Create(&transfer).Error; err != nil {
+			return err
+		}
+		return recordClassEvent(tx, classID, models.ClassEventTransferProposed, actorID, actorRole,
+			fmt.Sprintf("transfer %d from admin %d to admin %d", transfer.ID, transfer.FromAdminID, toAdminID))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+func ListIncomingClassTransfers(adminID uint) ([]IncomingClassTransfer, error) {
+	var transfers []IncomingClassTransfer
+	err := DB.Model(&models.ClassTransfer{}).
+		Select("class_transfers.id, class_transfers.class_id, classes.name AS class_name, class_transfers.from_admin_id, class_transfers.proposed_by_role, class_transfers.note, class_transfers.created_at").
+		Joins("JOIN classes ON classes.id = class_transfers.class_id").
+		Where("class_transfers.to_admin_id = ? AND class_transfers.status = ?", adminID, models.ClassTransferPending).
+		Order("class_transfers.id DESC").
+		Scan(&transfers).Error
+	return transfers, err
+}
+
+// lockPendingTransfer loads a pending transfer for update.
+func lockPendingTransfer(tx *gorm.DB, query string, args ...interface{}) (*models.ClassTransfer, error) {
+	var transfer models.ClassTransfer
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("status = ?", models.ClassTransferPending).
+		Where(query, args...).
+		First(&transfer).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrClassTransferNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+func closeTransfer(tx *gorm.DB, transfer *models.ClassTransfer, status string) error {
+	return tx.Model(transfer).Updates(map[string]interface{}{
+		"status":       status,
+		"responded_at": time.Now(),
+	}).Error
+}
+
+// AcceptClassTransfer makes adminID the owner of the transferred class. The
+// previous owner stays on as a co-teacher so the new owner decides whether
+// they keep access. Enrollments and attendance belong to the class and are
+// not touched.
+func AcceptClassTransfer(transferID uint, adminID uint) (*models.ClassTransfer, error) {
+	var accepted *models.ClassTransfer
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		transfer, err := lockPendingTransfer(tx, "id = ? AND to_admin_id = ?", transferID, adminID)
+		if err != nil {
+			return err
+		}
+
+		var class models.Classes
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", transfer.ClassID).First(&class).Error; err != nil {
+			return err
+		}
+		if class.CreatedByAdminId != transfer.FromAdminID {
+			if err := closeTransfer(tx, transfer, models.ClassTransferCancelled); err != nil {
+				return err
+			}
+			return ErrClassTransferStale
+		}
+
+		if err := tx.Model(&class).Update("created_by_admin_id", adminID).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("class_id = ? AND admin_id = ?", class.ID, adminID).Delete(&models.ClassStaff{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.ClassStaff{}).
+			Where("class_id = ? AND admin_id = ?", class.ID, transfer.FromAdminID).
+			Update("role", models.StaffRoleCoTeacher).Error; err != nil {
+			return err
+		}
+		now := time.Now()
+		if err := tx.Create(&models.ClassStaff{
+			ClassID:          class.ID,
+			AdminID:          adminID,
+			Role:             models.StaffRoleOwner,
+			InvitedByAdminID: transfer.ProposedByID,
+			AcceptedAt:       &now,
+		}).Error; err != nil {
+			return err
+		}
+
+		if err := closeTransfer(tx, transfer, models.ClassTransferAccepted); err != nil {
+			return err
+		}
+		accepted = transfer
+		return recordClassEvent(tx, class.ID, models.ClassEventTransferAccepted, adminID, "admin",
+			fmt.Sprintf("transfer %d: owner changed from admin %d to admin %d", transfer.ID, transfer.FromAdminID, adminID))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return accepted, nil
+}
+
+func DeclineClassTransfer(transferID uint, adminID uint) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		transfer, err := lockPendingTransfer(tx, "id = ? AND to_admin_id = ?", transferID, adminID)
+		if err != nil {
+			return err
+		}
+		if err := closeTransfer(tx, transfer, models.ClassTransferDeclined); err != nil {
+			return err
+		}
+		return recordClassEvent(tx, transfer.ClassID, models.ClassEventTransferDeclined, adminID, "admin",
+			fmt.Sprintf("transfer %d declined", transfer.ID))
+	})
+}
+
+// CancelClassTransfer withdraws the class's pending transfer.
+func CancelClassTransfer(classID uint, actorID uint, actorRole string) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		transfer, err := lockPendingTransfer(tx, "class_id = ?", classID)
+		if err != nil {
+			return err
+		}
+		if err := closeTransfer(tx, transfer, models.ClassTransferCancelled); err != nil {
+			return err
+		}
+		return recordClassEvent(tx, classID, models.ClassEventTransferCancelled, actorID, actorRole,
+			fmt.Sprintf("transfer %d cancelled", transfer.ID))
+	})
+}
@@ -3,6 +3,7 @@ package dataprovider
 import (
 	// "gorm.io/gorm"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/hyphenXY/Streak-App/internal/models"
@@ -18,8 +19,27 @@ func IfClassExists(classID uint) (bool, error) {
 	return count > 0, nil
 }
 
+// CreateClass creates the class with its first class code and makes its
+// creator the owner.
 func CreateClass(class *models.Classes) error {
-	return DB.Create(class).Error
+	return withUniqueClassCode(func(tx *gorm.DB, code string) error {
+		class.ID = 0
+		class.ClassCode = code
+		if err := tx.Create(class).Error; err != nil {
+			return err
+		}
+		if _, err := createClassCode(tx, class.ID, code, ClassCodeOptions{}, class.CreatedByAdminId); err != nil {
+			return err
+		}
+		now := time.Now()
+		return tx.Create(&models.ClassStaff{
+			ClassID:          class.ID,
+			AdminID:          class.CreatedByAdminId,
+			Role:             models.StaffRoleOwner,
+			InvitedByAdminID: class.CreatedByAdminId,
+			AcceptedAt:       &now,
+		}).Error
+	})
 }
 
 func MarkAttendanceByUser(classID uint, userID uint, status string) error {
@@ -66,35 +86,22 @@ func MarkAttendanceByAdmin(classID uint, userID uint) error {
 	return errors.New("already marked")
 }
 
-func IsUserAdmin(userID uint, classID uint) (bool, error) {
-	var count int64
-	err := DB.Model(&models.Classes{}).Where("created_by_admin_id = ? AND id = ?", userID, classID).Count(&count).Error
-	if err != nil {
-		return false, err
-	}
-	return count > 0, nil
-}
-
-func GetStudentsByClassID(classID uint) ([]models.User, error) {
+// GetStudentsByClassID returns the class's students. Students who left or
+// were removed are only included with includeInactive.
+func GetStudentsByClassID(classID uint, includeInactive bool) ([]models.User, error) {
 	var students []models.User
-	err := DB.Joins("JOIN enrollments ON enrollments.user_id = users.id").
-		Where("enrollments.class_id = ?", classID).
-		Find(&students).Error
+	query := DB.Joins("JOIN user_classes ON user_classes.user_id = users.id").
+		Where("user_classes.class_id = ?", classID)
+	if !includeInactive {
+		query = query.Where("user_classes.inactive_at IS NULL")
+	}
+	err := query.Find(&students).Error
 	if err != nil {
 		return nil, err
 	}
 	return students, nil
 }
 
-func GetClassIDByCode(classCode string) (uint, error) {
-	var class models.Classes
-	err := DB.Where("class_code = ?", classCode).First(&class).Error
-	if err != nil {
-		return 0, err
-	}
-	return class.ID, nil
-}
-
 func GetClassByID(classID uint) (*models.Classes, error) {
 	var class models.Classes
 	err := DB.Where("id = ?", classID).First(&class).Error
@@ -244,8 +251,9 @@ func GetUserQuickSummary(userID uint, classID uint, role string) (map[string]int
 	return summary, nil
 }
 
+// IfAlreadyEnrolled reports whether the user is an active student of the class.
 func IfAlreadyEnrolled(userID uint, classID uint, enrollment *models.User_Classes) (bool, error) {
-	err := DB.Where("user_id = ? AND class_id = ?", userID, classID).First(enrollment).Error
+	err := DB.Where("user_id = ? AND class_id = ? AND inactive_at IS NULL", userID, classID).First(enrollment).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return false, nil // Not enrolled
@@ -255,13 +263,61 @@ func IfAlreadyEnrolled(userID uint, classID uint, enrollment *models.User_Classe
 	return true, nil // Already enrolled
 }
 
-func EnrollUser(userID uint, classID uint) error {
-	enrollment := models.User_Classes{
-		UserID:  userID,
-		ClassID: classID,
+// enrollUser makes the user an active student of the class. A student who
+// left or was removed gets their old enrollment back, so their attendance
+// history carries on; reactivated reports that case. Two enrollments racing
+// for the same user and class meet at idx_user_class, and the loser gets
+// ErrAlreadyEnrolled.
+func enrollUser(tx *gorm.DB, userID uint, classID uint) (reactivated bool, err error) {
+	var enrollment models.User_Classes
+	err = tx.Where("user_id = ? AND class_id = ?", userID, classID).
+		Order("inactive_at IS NULL DESC, id DESC").
+		First(&enrollment).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = tx.Create(&models.User_Classes{UserID: userID, ClassID: classID}).Error
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return false, ErrAlreadyEnrolled
+		}
+		return false, err
 	}
-	result := DB.Create(&enrollment)
-	return result.Error
+	if err != nil {
+		return false, err
+	}
+	if enrollment.InactiveAt == nil {
+		return false, ErrAlreadyEnrolled
+	}
+	return true, tx.Model(&enrollment).Updates(map[string]interface{}{
+		"inactive_at":         nil,
+		"removed_by_admin_id": nil,
+	}).Error
+}
+
+var ErrNotEnrolled = errors.New("user not enrolled")
+
+// DeactivateEnrollment takes the student out of the class while keeping
+// their enrollment and attendance. removedBy is nil when the student left on
+// their own.
+func DeactivateEnrollment(userID uint, classID uint, removedBy *uint) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.User_Classes{}).
+			Where("user_id = ? AND class_id = ? AND inactive_at IS NULL", userID, classID).
+			Updates(map[string]interface{}{
+				"inactive_at":         time.Now(),
+				"removed_by_admin_id": removedBy,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotEnrolled
+		}
+
+		if removedBy == nil {
+			return recordClassEvent(tx, classID, models.ClassEventStudentLeft, userID, "user", "")
+		}
+		return recordClassEvent(tx, classID, models.ClassEventStudentRemoved, *removedBy, "admin",
+			fmt.Sprintf("removed user %d", userID))
+	})
 }
 
 func GetClassSummary(classID uint) (map[string]interface{}, error) {
@@ -269,23 +325,29 @@ func GetClassSummary(classID uint) (map[string]interface{}, error) {
 
 	var totalStudents int64
 	if err := DB.Model(&models.User_Classes{}).
-		Where("class_id = ?", classID).
+		Where("class_id = ? AND inactive_at IS NULL", classID).
 		Count(&totalStudents).Error; err != nil {
 		return nil, err
 	}
 	summary["total_students"] = totalStudents
 
+	// Attendance counts only cover the students counted above, so a student
+	// who left takes their attendance out of the summary with them
+	studentAttendance := func(status string) *gorm.DB {
+		return DB.Model(&models.Attendance{}).
+			Joins("JOIN user_classes ON user_classes.user_id = attendances.marked_by_id AND user_classes.class_id = attendances.class_id").
+			Where("attendances.class_id = ? AND attendances.marked_by_role = ? AND attendances.status = ? AND user_classes.inactive_at IS NULL", classID, "user", status)
+	}
+
 	var totalPresent int64
-	if err := DB.Model(&models.Attendance{}).
-		Where("class_id = ? AND status = ?", classID, "present").
+	if err := studentAttendance("present").
 		Count(&totalPresent).Error; err != nil {
 		return nil, err
 	}
 	summary["total_present"] = totalPresent
 
 	var totalAbsent int64
-	if err := DB.Model(&models.Attendance{}).
-		Where("class_id = ? AND status = ?", classID, "absent").
+	if err := studentAttendance("absent").
 		Count(&totalAbsent).Error; err != nil {
 		return nil, err
 	}
@@ -293,16 +355,16 @@ func GetClassSummary(classID uint) (map[string]interface{}, error) {
 
 	// Current week present/absent (uses YEARWEEK to match earlier queries)
 	var currentWeekPresent int64
-	if err := DB.Model(&models.Attendance{}).
-		Where("class_id = ? AND status = ? AND YEARWEEK(created_at) = YEARWEEK(CURRENT_DATE)", classID, "present").
+	if err := studentAttendance("present").
+		Where("YEARWEEK(attendances.created_at) = YEARWEEK(CURRENT_DATE)").
 		Count(&currentWeekPresent).Error; err != nil {
 		return nil, err
 	}
 	summary["current_week_present"] = currentWeekPresent
 
 	var currentWeekAbsent int64
-	if err := DB.Model(&models.Attendance{}).
-		Where("class_id = ? AND status = ? AND YEARWEEK(created_at) = YEARWEEK(CURRENT_DATE)", classID, "absent").
+	if err := studentAttendance("absent").
+		Where("YEARWEEK(attendances.created_at) = YEARWEEK(CURRENT_DATE)").
 		Count(&currentWeekAbsent).Error; err != nil {
 		return nil, err
 	}
@@ -310,16 +372,16 @@ func GetClassSummary(classID uint) (map[string]interface{}, error) {
 
 	// Current month present/absent
 	var currentMonthPresent int64
-	if err := DB.Model(&models.Attendance{}).
-		Where("class_id = ? AND status = ? AND MONTH(created_at) = MONTH(CURRENT_DATE) AND YEAR(created_at) = YEAR(CURRENT_DATE)", classID, "present").
+	if err := studentAttendance("present").
+		Where("MONTH(attendances.created_at) = MONTH(CURRENT_DATE) AND YEAR(attendances.created_at) = YEAR(CURRENT_DATE)").
 		Count(&currentMonthPresent).Error; err != nil {
 		return nil, err
 	}
 	summary["current_month_present"] = currentMonthPresent
 
 	var currentMonthAbsent int64
-	if err := DB.Model(&models.Attendance{}).
-		Where("class_id = ? AND status = ? AND MONTH(created_at) = MONTH(CURRENT_DATE) AND YEAR(created_at) = YEAR(CURRENT_DATE)", classID, "absent").
+	if err := studentAttendance("absent").
+		Where("MONTH(attendances.created_at) = MONTH(CURRENT_DATE) AND YEAR(attendances.created_at) = YEAR(CURRENT_DATE)").
 		Count(&currentMonthAbsent).Error; err != nil {
 		return nil, err
 	}
@@ -4,6 +4,7 @@ import (
     "fmt"
     "log"
     "os"
+    "strings"
 
     "gorm.io/gorm"
     "gorm.io/driver/mysql"
@@ -29,7 +30,7 @@ func InitDB() error {
         user, password, host, port, dbname)
 
     var err error
-    DB, err = gorm.Open(mysql.Open(dsn), &gorm.Config{})
+    DB, err = gorm.Open(mysql.Open(dsn), &gorm.Config{TranslateError: true})
     if err != nil {
         return fmt.Errorf("error connecting to DB: %w", err)
     }
@@ -45,11 +46,158 @@ func InitDB() error {
         &models.User_Classes{},
         &models.Classes{},
         &models.OTPs{},
+        &models.RootInvite{},
+        &models.Session{},
+        &models.RefreshToken{},
+        &models.AuthEvent{},
+        &models.SignInLock{},
+        &models.TwoFactor{},
+        &models.RecoveryCode{},
+        &models.TwoFactorChallenge{},
+        &models.Setting{},
+        &models.EmailVerification{},
+        &models.PersonalAccessToken{},
+        &models.ClassStaff{},
+        &models.ClassTransfer{},
+        &models.ClassEvent{},
+        &models.ClassCode{},
+        &models.EnrollmentRequest{},
+        &models.PlaceholderAccount{},
+        &models.PlaceholderEnrollment{},
     )
     if err != nil {
         return fmt.Errorf("auto migration failed: %w", err)
     }
 
+    // Refresh tokens moved to the sessions table
+    for _, model := range []interface{}{&models.User{}, &models.Admin{}, &models.Root{}} {
+        for _, column := range []string{"refresh_token", "refresh_token_expiry"} {
+            if DB.Migrator().HasColumn(model, column) {
+                if err := DB.Migrator().DropColumn(model, column); err != nil {
+                    return fmt.Errorf("dropping %s failed: %w", column, err)
+                }
+            }
+        }
+    }
+
+    // Sessions used to keep their refresh token in plaintext; carry live
+    // ones over as hashes so nobody is logged out, then drop the column.
+    if DB.Migrator().HasColumn(&models.Session{}, "refresh_token") {
+        if err := DB.Exec(`INSERT INTO refresh_tokens (session_id, token_hash, expiry, created_at, updated_at)
+            SELECT id, SHA2(refresh_token, 256), expiry, NOW(), NOW() FROM sessions
+            WHERE revoked_at IS NULL AND refresh_token <> ''`).Error; err != nil {
+            return fmt.Errorf("hashing session refresh tokens failed: %w", err)
+        }
+        if err := DB.Migrator().DropColumn(&models.Session{}, "refresh_token"); err != nil {
+            return fmt.Errorf("dropping sessions.refresh_token failed: %w", err)
+        }
+    }
+
+    // Root invites used to keep their token in plaintext
+    if DB.Migrator().HasColumn(&models.RootInvite{}, "token") {
+        if err := DB.Exec(`UPDATE root_invites SET token_hash = SHA2(token, 256)
+            WHERE (token_hash IS NULL OR token_hash = '') AND token <> ''`).Error; err != nil {
+            return fmt.Errorf("hashing root invite tokens failed: %w", err)
+        }
+        if err := DB.Migrator().DropColumn(&models.RootInvite{}, "token"); err != nil {
+            return fmt.Errorf("dropping root_invites.token failed: %w", err)
+        }
+    }
+
+    // OTPs used to keep their code in plaintext
+    if DB.Migrator().HasColumn(&models.OTPs{}, "otp") {
+        if err := DB.Exec(`UPDATE otps SET code_hash = SHA2(otp, 256)
+            WHERE (code_hash IS NULL OR code_hash = '') AND otp <> ''`).Error; err != nil {
+            return fmt.Errorf("hashing OTP codes failed: %w", err)
+        }
+        if err := DB.Migrator().DropColumn(&models.OTPs{}, "otp"); err != nil {
+            return fmt.Errorf("dropping otps.otp failed: %w", err)
+        }
+    }
+
+    // Phones are stored in E.164 now. Everything before that was Indian and
+    // stored as the bare 10-digit number.
+    for _, model := range []interface{}{&models.User{}, &models.Admin{}, &models.Root{}, &models.Classes{}, &models.OTPs{}} {
+        if err := DB.Model(model).
+            Where("phone REGEXP ?", "^[0-9]{10}$").
+            Update("phone", gorm.Expr("CONCAT('+91', phone)")).Error; err != nil {
+            return fmt.Errorf("migrating phones to E.164 failed: %w", err)
+        }
+    }
+    for _, table := range []string{"users", "admins", "roots"} {
+        if err := ensureUniquePhones(table); err != nil {
+            return err
+        }
+    }
+
+    // Enrollments weren't unique either. Of each user's rows for a class
+    // keep the active one, or else the newest, as enrollUser would pick;
+    // attendance is keyed by user and class so none of it is lost.
+    if !DB.Migrator().HasIndex(&models.User_Classes{}, "idx_user_class") {
+        if err := DB.Exec(`DELETE uc FROM user_classes uc
+            JOIN user_classes keep ON keep.user_id = uc.user_id AND keep.class_id = uc.class_id
+            AND ((keep.inactive_at IS NULL AND uc.inactive_at IS NOT NULL)
+                OR ((keep.inactive_at IS NULL) = (uc.inactive_at IS NULL) AND keep.id > uc.id))`).Error; err != nil {
+            return fmt.Errorf("merging duplicate enrollments failed: %w", err)
+        }
+        if err := DB.Exec("CREATE UNIQUE INDEX idx_user_class ON user_classes (user_id, class_id)").Error; err != nil {
+            return fmt.Errorf("creating idx_user_class failed: %w", err)
+        }
+    }
+
+    // Class ownership used to live only in classes.created_by_admin_id
+    if err := DB.Exec(`INSERT INTO class_staffs (class_id, admin_id, role, invited_by_admin_id, accepted_at, created_at, updated_at)
+        SELECT classes.id, classes.created_by_admin_id, 'owner', classes.created_by_admin_id, classes.created_at, NOW(), NOW() FROM classes
+        WHERE classes.created_by_admin_id <> 0
+        AND NOT EXISTS (SELECT 1 FROM class_staffs WHERE class_staffs.class_id = classes.id AND class_staffs.role = 'owner')`).Error; err != nil {
+        return fmt.Errorf("backfilling class owners failed: %w", err)
+    }
+
+    // Class codes used to live only in classes.class_code
+    if err := DB.Exec(`INSERT INTO class_codes (class_id, code, label, uses, created_by_admin_id, created_at, updated_at)
+        SELECT classes.id, UPPER(classes.class_code), '', 0, classes.created_by_admin_id, classes.created_at, NOW() FROM classes
+        WHERE classes.class_code <> ''
+        AND NOT EXISTS (SELECT 1 FROM class_codes WHERE class_codes.class_id = classes.id)`).Error; err != nil {
+        return fmt.Errorf("backfilling class codes failed: %w", err)
+    }
+    if err := DB.Exec("UPDATE classes SET class_code = UPPER(class_code) WHERE BINARY class_code <> BINARY UPPER(class_code)").Error; err != nil {
+        return fmt.Errorf("uppercasing class codes failed: %w", err)
+    }
+
     log.Println("✅ Tables migrated successfully!")
     return nil
 }
+
+// ensureUniquePhones adds the unique phone index of an account table. Phones
+// weren't unique before, so it refuses to start while two accounts share one
+// and names them; those have to be merged or given another phone by hand.
+func ensureUniquePhones(table string) error {
+    index := "idx_" + table + "_phone"
+    if DB.Migrator().HasIndex(table, index) {
+        return nil
+    }
+
+    var duplicates []struct {
+        Phone string
+        IDs   string
+    }
+    if err := DB.Table(table).
+        Select("phone, GROUP_CONCAT(id ORDER BY id) AS ids").
+        Group("phone").
+        Having("COUNT(*) > 1").
+        Scan(&duplicates).Error; err != nil {
+        return fmt.Errorf("checking %s for duplicate phones failed: %w", table, err)
+    }
+    if len(duplicates) > 0 {
+        conflicts := make([]string, 0, len(duplicates))
+        for _, d := range duplicates {
+            conflicts = append(conflicts, fmt.Sprintf("%q: ids %s", d.Phone, d.IDs))
+        }
+        return fmt.Errorf("%s share phones, resolve them before starting: %s", table, strings.Join(conflicts, "; "))
+    }
+
+    if err := DB.Exec(fmt.Sprintf("CREATE UNIQUE INDEX %s ON %s (phone)", index, table)).Error; err != nil {
+        return fmt.Errorf("creating %s failed: %w", index, err)
+    }
+    return nil
+}
@@ -0,0 +1,132 @@
+package dataprovider
+
+import (
+	"crypto/subtle"
+	"errors"
+	"time"
+
+	"github.com/hyphenXY/Streak-App/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrEmailVerificationNotFound = errors.New("email verification not found")
+	ErrEmailVerificationMismatch = errors.New("email verification code mismatch")
+	ErrEmailVerificationExpired  = errors.New("email verification expired")
+	ErrEmailVerificationLocked   = errors.New("email verification locked after too many attempts")
+	ErrEmailChanged              = errors.New("email changed since the code was sent")
+)
+
+// AccountContact is the email side of a user or admin account.
+type AccountContact struct {
+	Email           string
+	EmailVerifiedAt *time.Time
+	OTPChannel      string
+}
+
+// GetAccountContact loads the email, its verification time and the OTP
+// channel of a user or admin.
+func GetAccountContact(subjectID uint, role string) (*AccountContact, error) {
+	var contact AccountContact
+	result := DB.Model(accountModel(role)).
+		Select("email", "email_verified_at", "otp_channel").
+		Where("id = ?", subjectID).
+		Scan(&contact)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &contact, nil
+}
+
+// GetLatestEmailVerification returns the newest code sent to a subject, or
+// gorm.ErrRecordNotFound.
+func GetLatestEmailVerification(subjectID uint, role string) (*models.EmailVerification, error) {
+	var v models.EmailVerification
+	if err := DB.Where("subject_id = ? AND role = ?", subjectID, role).
+		Order("id DESC").
+		First(&v).Error; err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+func CreateEmailVerification(v *models.EmailVerification) error {
+	return DB.Create(v).Error
+}
+
+// ConsumeEmailVerification checks codeHash against the latest unused code of
+// a subject. On a match the code is used up and the account's email is marked
+// verified, provided it hasn't changed since. A wrong code counts as an
+// attempt, and the code locks once maxAttempts is reached.
+func ConsumeEmailVerification(subjectID uint, role string, codeHash string, maxAttempts int) error {
+	// As with ConsumeOTP, attempts must be committed even when the code is
+	// wrong, so the outcome is reported through result.
+	var result error
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		var v models.EmailVerification
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("subject_id = ? AND role = ? AND used_at IS NULL", subjectID, role).
+			Order("id DESC").
+			First(&v).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			result = ErrEmailVerificationNotFound
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if v.Attempts >= maxAttempts {
+			result = ErrEmailVerificationLocked
+			return nil
+		}
+		if time.Now().After(v.Expiry) {
+			result = ErrEmailVerificationExpired
+			return nil
+		}
+
+		if subtle.ConstantTimeCompare([]byte(v.CodeHash), []byte(codeHash)) != 1 {
+			result = ErrEmailVerificationMismatch
+			if v.Attempts+1 >= maxAttempts {
+				result = ErrEmailVerificationLocked
+			}
+			return tx.Model(&v).Update("attempts", gorm.Expr("attempts + 1")).Error
+		}
+
+		now := time.Now()
+		if err := tx.Model(&v).Update("used_at", now).Error; err != nil {
+			return err
+		}
+		updated := tx.Model(accountModel(role)).
+			Where("id = ? AND email = ?", subjectID, v.Email).
+			Update("email_verified_at", now)
+		if updated.Error != nil {
+			return updated.Error
+		}
+		if updated.RowsAffected == 0 {
+			result = ErrEmailChanged
+			return nil
+		}
+		if role == "user" {
+			// Classes an admin imported this address into
+			_, err := ClaimPlaceholders(tx, subjectID, "", v.Email)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return result
+}
+
+// SetOTPChannel changes where a user's or admin's OTPs are delivered.
+func SetOTPChannel(subjectID uint, role string, channel string) error {
+	return DB.Model(accountModel(role)).
+		Where("id = ?", subjectID).
+		Update("otp_channel", channel).Error
+}
@@ -0,0 +1,125 @@
+package dataprovider
+
+import (
+	"errors"
+	"time"
+
+	"github.com/hyphenXY/Streak-App/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrEnrollmentPending = errors.New("enrollment request already pending")
+
+// ClassEnrollmentRequest is a request as seen by the class admins.
+type ClassEnrollmentRequest struct {
+	ID         uint       `json:"id"`
+	UserID     uint       `json:"user_id"`
+	UserName   string     `json:"user_name"`
+	FirstName  string     `json:"first_name"`
+	LastName   string     `json:"last_name"`
+	Status     string     `json:"status"`
+	Reason     string     `json:"reason"`
+	ReviewedAt *time.Time `json:"reviewed_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// UserEnrollmentRequest is a request as seen by the student who made it.
+type UserEnrollmentRequest struct {
+	ID         uint       `json:"id"`
+	ClassID    uint       `json:"class_id"`
+	ClassName  string     `json:"class_name"`
+	Status     string     `json:"status"`
+	Reason     string     `json:"reason"`
+	ReviewedAt *time.Time `json:"reviewed_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func createEnrollmentRequest(tx *gorm.DB, classID uint, userID uint, classCodeID uint) error {
+	var pending int64
+	if err := tx.Model(&models.EnrollmentRequest{}).
+		Where("class_id = ? AND user_id = ? AND status = ?", classID, userID, models.EnrollmentRequestPending).
+		Count(&pending).Error; err != nil {
+		return err
+	}
+	if pending > 0 {
+		return ErrEnrollmentPending
+	}
+	return tx.Create(&models.EnrollmentRequest{
+		ClassID:     classID,
+		UserID:      userID,
+		ClassCodeID: classCodeID,
+		Status:      models.EnrollmentRequestPending,
+	}).Error
+}
+
+// ListEnrollmentRequests returns a class's requests, oldest first. An empty
+// status returns requests of every status.
+func ListEnrollmentRequests(classID uint, status string) ([]ClassEnrollmentRequest, error) {
+	var requests []ClassEnrollmentRequest
+	query := DB.Model(&models.EnrollmentRequest{}).
+		Select("enrollment_requests.id, enrollment_requests.user_id, users.user_name, users.first_name, users.last_name, enrollment_requests.status, enrollment_requests.reason, enrollment_requests.reviewed_at, enrollment_requests.created_at").
+		Joins("JOIN users ON users.id = enrollment_requests.user_id").
+		Where("enrollment_requests.class_id = ?", classID)
+	if status != "" {
+		query = query.Where("enrollment_requests.status = ?", status)
+	}
+	err := query.Order("enrollment_requests.id ASC").Scan(&requests).Error
+	return requests, err
+}
+
+func ListUserEnrollmentRequests(userID uint) ([]UserEnrollmentRequest, error) {
+	var requests []UserEnrollmentRequest
+	err := DB.Model(&models.EnrollmentRequest{}).
+		Select("enrollment_requests.id, enrollment_requests.class_id, classes.name AS class_name, enrollment_requests.status, enrollment_requests.reason, enrollment_requests.reviewed_at, enrollment_requests.created_at").
+		Joins("JOIN classes ON classes.id = enrollment_requests.class_id").
+		Where("enrollment_requests.user_id = ?", userID).
+		Order("enrollment_requests.id DESC").
+		Scan(&requests).Error
+	return requests, err
+}
+
+// ReviewEnrollmentRequests approves or rejects the class's pending requests
+// among requestIDs, enrolling the students of approved ones. It returns the
+// IDs it reviewed; the rest were not pending requests of this class.
+func ReviewEnrollmentRequests(classID uint, requestIDs []uint, approve bool, reason string, adminID uint) ([]uint, error) {
+	var reviewed []uint
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		var requests []models.EnrollmentRequest
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ? AND class_id = ? AND status = ?", requestIDs, classID, models.EnrollmentRequestPending).
+			Find(&requests).Error; err != nil {
+			return err
+		}
+		if len(requests) == 0 {
+			return nil
+		}
+
+		status := models.EnrollmentRequestRejected
+		if approve {
+			status = models.EnrollmentRequestApproved
+		}
+
+		for _, request := range requests {
+			if approve {
+				if _, err := enrollUser(tx, request.UserID, classID); err != nil && !errors.Is(err, ErrAlreadyEnrolled) {
+					return err
+				}
+			}
+			reviewed = append(reviewed, request.ID)
+		}
+
+		return tx.Model(&models.EnrollmentRequest{}).
+			Where("id IN ?", reviewed).
+			Updates(map[string]interface{}{
+				"status":               status,
+				"reason":               reason,
+				"reviewed_by_admin_id": adminID,
+				"reviewed_at":          time.Now(),
+			}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reviewed, nil
+}
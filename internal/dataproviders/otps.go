@@ -0,0 +1,155 @@
+package dataprovider
+
+import (
+	"crypto/subtle"
+	"errors"
+	"time"
+
+	"github.com/hyphenXY/Streak-App/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrOTPNotFound   = errors.New("OTP not found")
+	ErrOTPMismatch   = errors.New("OTP mismatch")
+	ErrOTPExpired    = errors.New("OTP expired")
+	ErrOTPLocked     = errors.New("OTP locked after too many attempts")
+	ErrInvalidTicket = errors.New("invalid or expired verification ticket")
+)
+
+// StoreOTP records an OTP sent to phone, known to the database only by
+// codeHash.
+func StoreOTP(phone string, role string, purpose string, codeHash string, expiry time.Time, requestIP string) error {
+	otpRecord := models.OTPs{
+		Phone:     phone,
+		Role:      role,
+		Purpose:   purpose,
+		CodeHash:  codeHash,
+		Expiry:    expiry,
+		RequestIP: requestIP,
+	}
+	return DB.Create(&otpRecord).Error
+}
+
+// OTPSendStats describes recent OTP sends, used to enforce send quotas.
+type OTPSendStats struct {
+	PhoneCount    int64
+	IPCount       int64
+	LastPhoneSend *time.Time
+	// Oldest sends inside the window; the quota frees up when they age out.
+	OldestPhoneSend *time.Time
+	OldestIPSend    *time.Time
+}
+
+// GetOTPSendStats counts OTPs sent to phone and requested from ip since the
+// given time.
+func GetOTPSendStats(phone string, ip string, since time.Time) (*OTPSendStats, error) {
+	var stats OTPSendStats
+
+	var phoneRow struct {
+		Count  int64
+		Oldest *time.Time
+		Latest *time.Time
+	}
+	if err := DB.Model(&models.OTPs{}).
+		Select("COUNT(*) AS count, MIN(created_at) AS oldest, MAX(created_at) AS latest").
+		Where("phone = ? AND created_at > ?", phone, since).
+		Scan(&phoneRow).Error; err != nil {
+		return nil, err
+	}
+	stats.PhoneCount = phoneRow.Count
+	stats.OldestPhoneSend = phoneRow.Oldest
+	stats.LastPhoneSend = phoneRow.Latest
+
+	var ipRow struct {
+		Count  int64
+		Oldest *time.Time
+	}
+	if err := DB.Model(&models.OTPs{}).
+		Select("COUNT(*) AS count, MIN(created_at) AS oldest").
+		Where("request_ip = ? AND created_at > ?", ip, since).
+		Scan(&ipRow).Error; err != nil {
+		return nil, err
+	}
+	stats.IPCount = ipRow.Count
+	stats.OldestIPSend = ipRow.Oldest
+
+	return &stats, nil
+}
+
+// ConsumeOTP checks codeHash against the most recent unconsumed OTP for phone,
+// role and purpose. On a match the OTP is consumed and a verification ticket, known to
+// the database only by ticketHash, is attached to it. A wrong code counts as
+// an attempt, and the OTP locks once maxAttempts is reached.
+func ConsumeOTP(phone string, role string, purpose string, codeHash string, maxAttempts int, ticketHash string, ticketExpiry time.Time) error {
+	// The attempt counter must be committed even when the code is wrong, so
+	// the outcome is reported through result rather than the transaction.
+	var result error
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		var otpRecord models.OTPs
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("phone = ? AND role = ? AND purpose = ? AND consumed_at IS NULL", phone, role, purpose).
+			Order("id DESC").
+			First(&otpRecord).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			result = ErrOTPNotFound
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if otpRecord.LockedAt != nil {
+			result = ErrOTPLocked
+			return nil
+		}
+		if time.Now().After(otpRecord.Expiry) {
+			result = ErrOTPExpired
+			return nil
+		}
+
+		if subtle.ConstantTimeCompare([]byte(otpRecord.CodeHash), []byte(codeHash)) != 1 {
+			updates := map[string]interface{}{"attempts": gorm.Expr("attempts + 1")}
+			result = ErrOTPMismatch
+			if otpRecord.Attempts+1 >= maxAttempts {
+				updates["locked_at"] = time.Now()
+				result = ErrOTPLocked
+			}
+			return tx.Model(&otpRecord).Updates(updates).Error
+		}
+
+		return tx.Model(&otpRecord).Updates(map[string]interface{}{
+			"consumed_at":   time.Now(),
+			"ticket_hash":   ticketHash,
+			"ticket_expiry": ticketExpiry,
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+	return result
+}
+
+// RedeemVerificationTicket marks the ticket as used and runs fn in the same
+// transaction, so the ticket is only spent if fn succeeds.
+func RedeemVerificationTicket(phone string, role string, purpose string, ticketHash string, fn func(tx *gorm.DB) error) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		var otpRecord models.OTPs
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("ticket_hash = ? AND phone = ? AND role = ? AND purpose = ? AND ticket_used_at IS NULL AND ticket_expiry > ?",
+				ticketHash, phone, role, purpose, time.Now()).
+			First(&otpRecord).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidTicket
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Model(&otpRecord).Update("ticket_used_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return fn(tx)
+	})
+}
@@ -0,0 +1,62 @@
+package dataprovider
+
+import (
+	"errors"
+	"time"
+
+	"github.com/hyphenXY/Streak-App/internal/models"
+	"gorm.io/gorm"
+)
+
+var ErrPersonalAccessTokenNotFound = errors.New("personal access token not found")
+
+func CreatePersonalAccessToken(token *models.PersonalAccessToken) error {
+	return DB.Create(token).Error
+}
+
+// ListPersonalAccessTokens returns an admin's tokens that haven't been
+// revoked, newest first. Expired tokens are included so they can be cleaned up.
+func ListPersonalAccessTokens(adminID uint) ([]models.PersonalAccessToken, error) {
+	var tokens []models.PersonalAccessToken
+	err := DB.Where("admin_id = ? AND revoked_at IS NULL", adminID).
+		Order("id DESC").
+		Find(&tokens).Error
+	return tokens, err
+}
+
+func RevokePersonalAccessToken(tokenID uint, adminID uint) error {
+	result := DB.Model(&models.PersonalAccessToken{}).
+		Where("id = ? AND admin_id = ? AND revoked_at IS NULL", tokenID, adminID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrPersonalAccessTokenNotFound
+	}
+	return nil
+}
+
+// GetActivePersonalAccessToken looks a token up by hash and returns it only
+// if it is neither revoked nor expired.
+func GetActivePersonalAccessToken(tokenHash string) (*models.PersonalAccessToken, error) {
+	var token models.PersonalAccessToken
+	err := DB.Where("token_hash = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", tokenHash, time.Now()).
+		First(&token).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrPersonalAccessTokenNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// TouchPersonalAccessToken updates LastUsedAt, at most once a minute so busy
+// scripts don't write on every request.
+func TouchPersonalAccessToken(tokenID uint) error {
+	now := time.Now()
+	return DB.Model(&models.PersonalAccessToken{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", tokenID, now.Add(-time.Minute)).
+		Update("last_used_at", now).Error
+}
@@ -0,0 +1,109 @@
+package dataprovider
+
+import (
+	"errors"
+	"time"
+
+	"github.com/hyphenXY/Streak-App/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrRootAlreadyExists = errors.New("root already exists")
+	ErrInvalidRootInvite = errors.New("invalid or expired invite")
+	ErrRootInviteEmail   = errors.New("invite was issued for a different email")
+)
+
+// CreateFirstRoot creates root only while the roots table is still empty.
+// The count runs under a row lock so two concurrent registrations can't both
+// become the first root.
+func CreateFirstRoot(root *models.Root) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.Root{}).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrRootAlreadyExists
+		}
+		return tx.Create(root).Error
+	})
+}
+
+// CreateRootFromInvite consumes an unused, unexpired invite and creates root.
+// The invite only works for the email it was issued to.
+func CreateRootFromInvite(root *models.Root, tokenHash string) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		var invite models.RootInvite
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ? AND used_at IS NULL AND expiry > ?", tokenHash, time.Now()).
+			First(&invite).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidRootInvite
+		}
+		if err != nil {
+			return err
+		}
+		if invite.Email != root.Email {
+			return ErrRootInviteEmail
+		}
+
+		now := time.Now()
+		if err := tx.Model(&invite).Update("used_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Create(root).Error
+	})
+}
+
+func CreateRootInvite(invite *models.RootInvite) error {
+	return DB.Create(invite).Error
+}
+
+func GetRootProfile(rootID uint) (*models.Root, error) {
+	root := &models.Root{}
+	if err := DB.Where("id = ?", rootID).First(root).Error; err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// UpdateRootProfile updates the name and email of a root. See updateProfile.
+func UpdateRootProfile(req map[string]interface{}, rootID uint) error {
+	return updateProfile("root", req, rootID)
+}
+
+// RootOverview is what the root console shows on its homepage.
+type RootOverview struct {
+	Admins           int64 `json:"admins"`
+	SuspendedAdmins  int64 `json:"suspended_admins"`
+	Classes          int64 `json:"classes"`
+	ArchivedClasses  int64 `json:"archived_classes"`
+	Students         int64 `json:"students"`
+	PendingTransfers int64 `json:"pending_transfers"`
+}
+
+// GetRootOverview counts the accounts and classes across the whole app.
+func GetRootOverview() (*RootOverview, error) {
+	var overview RootOverview
+	counts := []struct {
+		query *gorm.DB
+		dest  *int64
+	}{
+		{DB.Model(&models.Admin{}), &overview.Admins},
+		{DB.Model(&models.Admin{}).Where("suspended_at IS NOT NULL"), &overview.SuspendedAdmins},
+		{DB.Model(&models.Classes{}), &overview.Classes},
+		{DB.Model(&models.Classes{}).Where("archived_at IS NOT NULL"), &overview.ArchivedClasses},
+		{DB.Model(&models.User{}), &overview.Students},
+		{DB.Model(&models.ClassTransfer{}).Where("status = ?", models.ClassTransferPending), &overview.PendingTransfers},
+	}
+	for _, count := range counts {
+		if err := count.query.Count(count.dest).Error; err != nil {
+			return nil, err
+		}
+	}
+	return &overview, nil
+}
@@ -0,0 +1,248 @@
+package dataprovider
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyphenXY/Streak-App/internal/models"
+	"gorm.io/gorm"
+)
+
+// Outcomes of one roster import row.
+const (
+	RosterEnrolled        = "enrolled"
+	RosterReactivated     = "reactivated"
+	RosterAlreadyEnrolled = "already_enrolled"
+	RosterInvited         = "invited"
+	RosterAlreadyInvited  = "already_invited"
+	RosterNotFound        = "not_found"
+	RosterConflict        = "conflict"
+)
+
+// errDryRun rolls back a dry-run import once every row has been resolved.
+var errDryRun = errors.New("dry run")
+
+// RosterRow is one student to import. Phone must already be in E.164 form
+// and Email lowercased.
+type RosterRow struct {
+	Line     int
+	UserName string
+	Email    string
+	Phone    string
+}
+
+type RosterResult struct {
+	Line     int    `json:"line"`
+	UserName string `json:"username,omitempty"`
+	Email    string `json:"email,omitempty"`
+	Phone    string `json:"phone,omitempty"`
+	Status   string `json:"status"`
+	UserID   uint   `json:"user_id,omitempty"`
+	Message  string `json:"message,omitempty"`
+}
+
+// ImportRoster enrolls existing users matching each row, and records a
+// placeholder for rows with an email or phone that matches nobody. Admin
+// imports skip enrollment approval. With dryRun nothing is written, but the
+// results are the ones a real import would give.
+func ImportRoster(classID uint, adminID uint, rows []RosterRow, dryRun bool) ([]RosterResult, error) {
+	results := make([]RosterResult, 0, len(rows))
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		for _, row := range rows {
+			result, err := importRosterRow(tx, classID, adminID, row)
+			if err != nil {
+				return fmt.Errorf("line %d: %w", row.Line, err)
+			}
+			results = append(results, result)
+		}
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		return nil, err
+	}
+	return results, nil
+}
+
+func importRosterRow(tx *gorm.DB, classID uint, adminID uint, row RosterRow) (RosterResult, error) {
+	result := RosterResult{Line: row.Line, UserName: row.UserName, Email: row.Email, Phone: row.Phone}
+
+	// 1️⃣ Look for existing users matching any of the given fields. Anyone can
+	// type an address at signup, so like ClaimPlaceholders only verified
+	// emails count.
+	var conditions []string
+	var args []interface{}
+	for _, field := range []struct{ condition, value string }{
+		{"user_name = ?", row.UserName},
+		{"(email = ? AND email_verified_at IS NOT NULL)", row.Email},
+		{"phone = ?", row.Phone},
+	} {
+		if field.value != "" {
+			conditions = append(conditions, field.condition)
+			args = append(args, field.value)
+		}
+	}
+	var userIDs []uint
+	if err := tx.Model(&models.User{}).
+		Where(strings.Join(conditions, " OR "), args...).
+		Distinct().
+		Pluck("id", &userIDs).Error; err != nil {
+		return result, err
+	}
+
+	switch {
+	case len(userIDs) > 1:
+		result.Status = RosterConflict
+		result.Message = "fields match different users"
+		return result, nil
+
+	case len(userIDs) == 1:
+		result.UserID = userIDs[0]
+		reactivated, err := enrollUser(tx, userIDs[0], classID)
+		switch {
+		case errors.Is(err, ErrAlreadyEnrolled):
+			result.Status = RosterAlreadyEnrolled
+			return result, nil
+		case err != nil:
+			return result, err
+		case reactivated:
+			result.Status = RosterReactivated
+		default:
+			result.Status = RosterEnrolled
+		}
+		// Importing a student settles any request they had open
+		err = tx.Model(&models.EnrollmentRequest{}).
+			Where("class_id = ? AND user_id = ? AND status = ?", classID, userIDs[0], models.EnrollmentRequestPending).
+			Updates(map[string]interface{}{
+				"status":               models.EnrollmentRequestApproved,
+				"reason":               "added by roster import",
+				"reviewed_by_admin_id": adminID,
+				"reviewed_at":          time.Now(),
+			}).Error
+		return result, err
+	}
+
+	// 2️⃣ Nobody matched: invite by email or phone
+	if row.Email == "" && row.Phone == "" {
+		result.Status = RosterNotFound
+		result.Message = "no user with this username; add an email or phone to invite them"
+		return result, nil
+	}
+
+	placeholder, err := findOrCreatePlaceholder(tx, row.Email, row.Phone, adminID)
+	if err != nil {
+		return result, err
+	}
+
+	var invited int64
+	if err := tx.Model(&models.PlaceholderEnrollment{}).
+		Where("placeholder_account_id = ? AND class_id = ?", placeholder.ID, classID).
+		Count(&invited).Error; err != nil {
+		return result, err
+	}
+	if invited > 0 {
+		result.Status = RosterAlreadyInvited
+		return result, nil
+	}
+	if err := tx.Create(&models.PlaceholderEnrollment{
+		PlaceholderAccountID: placeholder.ID,
+		ClassID:              classID,
+		InvitedByAdminID:     adminID,
+	}).Error; err != nil {
+		return result, err
+	}
+	result.Status = RosterInvited
+	return result, nil
+}
+
+// findOrCreatePlaceholder returns the unclaimed placeholder for the phone, or
+// else the email, creating one if there is none.
+func findOrCreatePlaceholder(tx *gorm.DB, email string, phone string, adminID uint) (*models.PlaceholderAccount, error) {
+	var placeholder models.PlaceholderAccount
+	for _, lookup := range []struct{ column, value string }{{"phone", phone}, {"email", email}} {
+		if lookup.value == "" {
+			continue
+		}
+		err := tx.Where(lookup.column+" = ? AND claimed_at IS NULL", lookup.value).First(&placeholder).Error
+		if err == nil {
+			return &placeholder, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+
+	placeholder = models.PlaceholderAccount{
+		Email:            email,
+		Phone:            phone,
+		CreatedByAdminID: adminID,
+	}
+	if err := tx.Create(&placeholder).Error; err != nil {
+		return nil, err
+	}
+	return &placeholder, nil
+}
+
+// ClaimPlaceholders hands the unclaimed placeholders matching a verified
+// phone or email over to userID, enrolling them in the placeholders' classes
+// that are still active. Pass "" for a contact that isn't verified. It
+// returns the IDs of the classes joined.
+func ClaimPlaceholders(tx *gorm.DB, userID uint, phone string, email string) ([]uint, error) {
+	if phone == "" && email == "" {
+		return nil, nil
+	}
+
+	query := tx.Where("claimed_at IS NULL")
+	switch {
+	case phone != "" && email != "":
+		query = query.Where("phone = ? OR email = ?", phone, email)
+	case phone != "":
+		query = query.Where("phone = ?", phone)
+	default:
+		query = query.Where("email = ?", email)
+	}
+	var placeholders []models.PlaceholderAccount
+	if err := query.Find(&placeholders).Error; err != nil {
+		return nil, err
+	}
+	if len(placeholders) == 0 {
+		return nil, nil
+	}
+
+	placeholderIDs := make([]uint, 0, len(placeholders))
+	for _, p := range placeholders {
+		placeholderIDs = append(placeholderIDs, p.ID)
+	}
+
+	var classIDs []uint
+	if err := tx.Model(&models.PlaceholderEnrollment{}).
+		Joins("JOIN classes ON classes.id = placeholder_enrollments.class_id").
+		Where("placeholder_enrollments.placeholder_account_id IN ? AND classes.archived_at IS NULL", placeholderIDs).
+		Distinct().
+		Pluck("placeholder_enrollments.class_id", &classIDs).Error; err != nil {
+		return nil, err
+	}
+
+	joined := make([]uint, 0, len(classIDs))
+	for _, classID := range classIDs {
+		if _, err := enrollUser(tx, userID, classID); err != nil {
+			if errors.Is(err, ErrAlreadyEnrolled) {
+				continue
+			}
+			return nil, err
+		}
+		joined = append(joined, classID)
+	}
+
+	err := tx.Model(&models.PlaceholderAccount{}).
+		Where("id IN ?", placeholderIDs).
+		Updates(map[string]interface{}{
+			"claimed_by_user_id": userID,
+			"claimed_at":         time.Now(),
+		}).Error
+	return joined, err
+}
@@ -0,0 +1,179 @@
+package dataprovider
+
+import (
+	"errors"
+	"time"
+
+	"github.com/hyphenXY/Streak-App/internal/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrSessionNotFound     = errors.New("session not found")
+	ErrRefreshTokenInvalid = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reused")
+)
+
+// CreateSession stores session together with its first refresh token. Only
+// the hash of the token is stored.
+func CreateSession(session *models.Session, tokenHash string) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(session).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.RefreshToken{
+			SessionID: session.ID,
+			TokenHash: tokenHash,
+			Expiry:    session.Expiry,
+		}).Error
+	})
+}
+
+// GetRefreshToken looks a refresh token up by hash, along with its session.
+// Rotated tokens and revoked sessions are returned too, so the caller can
+// tell reuse apart from an unknown token.
+func GetRefreshToken(tokenHash string) (*models.RefreshToken, *models.Session, error) {
+	var token models.RefreshToken
+	if err := DB.Where("token_hash = ?", tokenHash).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrRefreshTokenInvalid
+		}
+		return nil, nil, err
+	}
+
+	var session models.Session
+	if err := DB.Where("id = ?", token.SessionID).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrRefreshTokenInvalid
+		}
+		return nil, nil, err
+	}
+	return &token, &session, nil
+}
+
+// RotateRefreshToken retires current and issues newHash in its place. If
+// current was rotated concurrently it returns ErrRefreshTokenReused.
+func RotateRefreshToken(current *models.RefreshToken, newHash string, expiry time.Time, ip string, userAgent string) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND rotated_at IS NULL", current.ID).
+			Update("rotated_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrRefreshTokenReused
+		}
+
+		if err := tx.Create(&models.RefreshToken{
+			SessionID: current.SessionID,
+			TokenHash: newHash,
+			Expiry:    expiry,
+		}).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&models.Session{}).
+			Where("id = ?", current.SessionID).
+			Updates(map[string]interface{}{
+				"expiry":       expiry,
+				"ip":           ip,
+				"user_agent":   userAgent,
+				"last_used_at": now,
+			}).Error
+	})
+}
+
+// RevokeSessionForReuse revokes a session whose rotated refresh token was
+// presented again and records the incident as an auth event.
+func RevokeSessionForReuse(session *models.Session, ip string, userAgent string) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Session{}).
+			Where("id = ? AND revoked_at IS NULL", session.ID).
+			Update("revoked_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.AuthEvent{
+			Type:      models.AuthEventRefreshTokenReuse,
+			Role:      session.Role,
+			SubjectID: session.SubjectID,
+			SessionID: &session.ID,
+			IP:        ip,
+			UserAgent: userAgent,
+			Details:   "rotated refresh token presented again; session revoked",
+		}).Error
+	})
+}
+
+func RevokeSessionByRefreshToken(tokenHash string) error {
+	var token models.RefreshToken
+	if err := DB.Where("token_hash = ?", tokenHash).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	return DB.Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NULL", token.SessionID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// activeSessions narrows a query to sessions that are neither revoked nor
+// expired.
+func activeSessions(db *gorm.DB) *gorm.DB {
+	return db.Where("revoked_at IS NULL AND expiry > ?", time.Now())
+}
+
+func ListActiveSessions(subjectID uint, role string) ([]models.Session, error) {
+	var sessions []models.Session
+	err := DB.Scopes(activeSessions).
+		Where("subject_id = ? AND role = ?", subjectID, role).
+		Order("last_used_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// IsSessionActive reports whether the subject's session is still active, so
+// access tokens of a revoked session stop working before they expire.
+func IsSessionActive(sessionID uint, subjectID uint, role string) (bool, error) {
+	var count int64
+	err := DB.Model(&models.Session{}).Scopes(activeSessions).
+		Where("id = ? AND subject_id = ? AND role = ?", sessionID, subjectID, role).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// RevokeSession revokes one of the subject's own sessions.
+func RevokeSession(sessionID uint, subjectID uint, role string) error {
+	result := DB.Model(&models.Session{}).
+		Where("id = ? AND subject_id = ? AND role = ? AND revoked_at IS NULL", sessionID, subjectID, role).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// RevokeOtherSessions revokes every session of the subject except keepSessionID.
+func RevokeOtherSessions(subjectID uint, role string, keepSessionID uint) (int64, error) {
+	return revokeOtherSessions(DB, subjectID, role, keepSessionID)
+}
+
+func revokeOtherSessions(tx *gorm.DB, subjectID uint, role string, keepSessionID uint) (int64, error) {
+	result := tx.Model(&models.Session{}).
+		Where("subject_id = ? AND role = ? AND id <> ? AND revoked_at IS NULL", subjectID, role, keepSessionID).
+		Update("revoked_at", time.Now())
+	return result.RowsAffected, result.Error
+}
+
+// RevokeAllSessions revokes every session of the subject using tx, so it can
+// be part of a larger change such as a password reset.
+func RevokeAllSessions(tx *gorm.DB, subjectID uint, role string) error {
+	return tx.Model(&models.Session{}).
+		Where("subject_id = ? AND role = ? AND revoked_at IS NULL", subjectID, role).
+		Update("revoked_at", time.Now()).Error
+}
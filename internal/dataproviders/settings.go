@@ -0,0 +1,33 @@
+package dataprovider
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/hyphenXY/Streak-App/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GetBoolSetting reads a boolean Setting, returning false if it was never set.
+func GetBoolSetting(key string) (bool, error) {
+	var setting models.Setting
+	if err := DB.Where("`key` = ?", key).First(&setting).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return strconv.ParseBool(setting.Value)
+}
+
+func SetBoolSetting(key string, value bool) error {
+	return DB.Clauses(clause.OnConflict{
+		DoUpdates: clause.AssignmentColumns([]string{"value", "updated_at"}),
+	}).Create(&models.Setting{
+		Key:       key,
+		Value:     strconv.FormatBool(value),
+		UpdatedAt: time.Now(),
+	}).Error
+}
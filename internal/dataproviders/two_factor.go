@@ -0,0 +1,203 @@
+package dataprovider
+
+import (
+	"errors"
+	"time"
+
+	"github.com/hyphenXY/Streak-App/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrTOTPCodeReused        = errors.New("TOTP code already used")
+	ErrRecoveryCodeInvalid   = errors.New("invalid recovery code")
+	ErrTwoFactorChallenge    = errors.New("invalid two-factor challenge")
+	ErrTwoFactorChallengeMax = errors.New("too many two-factor attempts")
+)
+
+// GetUserNameByRole returns the username of an admin or root account.
+func GetUserNameByRole(subjectID uint, role string) (string, error) {
+	var model interface{}
+	switch role {
+	case "admin":
+		model = &models.Admin{}
+	case "root":
+		model = &models.Root{}
+	default:
+		model = &models.User{}
+	}
+	var userNames []string
+	if err := DB.Model(model).Where("id = ?", subjectID).Pluck("user_name", &userNames).Error; err != nil {
+		return "", err
+	}
+	if len(userNames) == 0 {
+		return "", gorm.ErrRecordNotFound
+	}
+	return userNames[0], nil
+}
+
+// GetTwoFactor returns the TOTP enrollment of a subject, enabled or not. It
+// returns gorm.ErrRecordNotFound if setup was never started.
+func GetTwoFactor(subjectID uint, role string) (*models.TwoFactor, error) {
+	var tf models.TwoFactor
+	if err := DB.Where("subject_id = ? AND role = ?", subjectID, role).First(&tf).Error; err != nil {
+		return nil, err
+	}
+	return &tf, nil
+}
+
+// SaveTwoFactorSecret starts (or restarts) TOTP setup with a new secret. The
+// enrollment stays disabled until EnableTwoFactor.
+func SaveTwoFactorSecret(subjectID uint, role string, secret string) error {
+	return DB.Clauses(clause.OnConflict{
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"secret":         secret,
+			"enabled_at":     nil,
+			"last_used_step": 0,
+			"updated_at":     time.Now(),
+		}),
+	}).Create(&models.TwoFactor{
+		SubjectID: subjectID,
+		Role:      role,
+		Secret:    secret,
+	}).Error
+}
+
+// EnableTwoFactor turns on a pending enrollment after its first code was
+// checked at step, and replaces any recovery codes with codeHashes.
+func EnableTwoFactor(tf *models.TwoFactor, step int64, codeHashes []string) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.TwoFactor{}).
+			Where("id = ? AND enabled_at IS NULL AND last_used_step < ?", tf.ID, step).
+			Updates(map[string]interface{}{
+				"enabled_at":     time.Now(),
+				"last_used_step": step,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrTOTPCodeReused
+		}
+		return replaceRecoveryCodes(tx, tf.SubjectID, tf.Role, codeHashes)
+	})
+}
+
+// UseTOTPStep records that the code for step was accepted. It fails with
+// ErrTOTPCodeReused if that step, or a later one, was already used.
+func UseTOTPStep(tf *models.TwoFactor, step int64) error {
+	result := DB.Model(&models.TwoFactor{}).
+		Where("id = ? AND last_used_step < ?", tf.ID, step).
+		Update("last_used_step", step)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTOTPCodeReused
+	}
+	return nil
+}
+
+// UseRecoveryCode spends one unused recovery code of a subject.
+func UseRecoveryCode(subjectID uint, role string, codeHash string) error {
+	result := DB.Model(&models.RecoveryCode{}).
+		Where("subject_id = ? AND role = ? AND code_hash = ? AND used_at IS NULL", subjectID, role, codeHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRecoveryCodeInvalid
+	}
+	return nil
+}
+
+// ReplaceRecoveryCodes invalidates a subject's recovery codes and stores
+// codeHashes instead.
+func ReplaceRecoveryCodes(subjectID uint, role string, codeHashes []string) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		return replaceRecoveryCodes(tx, subjectID, role, codeHashes)
+	})
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, subjectID uint, role string, codeHashes []string) error {
+	if err := tx.Where("subject_id = ? AND role = ?", subjectID, role).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return err
+	}
+	codes := make([]models.RecoveryCode, 0, len(codeHashes))
+	for _, hash := range codeHashes {
+		codes = append(codes, models.RecoveryCode{SubjectID: subjectID, Role: role, CodeHash: hash})
+	}
+	if len(codes) == 0 {
+		return nil
+	}
+	return tx.Create(&codes).Error
+}
+
+// CountUnusedRecoveryCodes returns how many recovery codes a subject has left.
+func CountUnusedRecoveryCodes(subjectID uint, role string) (int64, error) {
+	var count int64
+	err := DB.Model(&models.RecoveryCode{}).
+		Where("subject_id = ? AND role = ? AND used_at IS NULL", subjectID, role).
+		Count(&count).Error
+	return count, err
+}
+
+// DeleteTwoFactor removes a subject's TOTP enrollment and recovery codes.
+func DeleteTwoFactor(subjectID uint, role string) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("subject_id = ? AND role = ?", subjectID, role).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("subject_id = ? AND role = ?", subjectID, role).Delete(&models.TwoFactor{}).Error
+	})
+}
+
+func CreateTwoFactorChallenge(challenge *models.TwoFactorChallenge) error {
+	return DB.Create(challenge).Error
+}
+
+// AttemptTwoFactorChallenge counts one attempt against the unused, unexpired
+// challenge with tokenHash and returns it. Once maxAttempts is reached the
+// challenge is burnt and ErrTwoFactorChallengeMax is returned.
+func AttemptTwoFactorChallenge(tokenHash string, role string, maxAttempts int) (*models.TwoFactorChallenge, error) {
+	var challenge models.TwoFactorChallenge
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ? AND role = ?", tokenHash, role).
+			First(&challenge).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrTwoFactorChallenge
+			}
+			return err
+		}
+		if challenge.UsedAt != nil || time.Now().After(challenge.Expiry) {
+			return ErrTwoFactorChallenge
+		}
+		if challenge.Attempts >= maxAttempts {
+			return ErrTwoFactorChallengeMax
+		}
+		challenge.Attempts++
+		return tx.Model(&challenge).Update("attempts", challenge.Attempts).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &challenge, nil
+}
+
+// CompleteTwoFactorChallenge marks a challenge as used so it can't start a
+// second session.
+func CompleteTwoFactorChallenge(challenge *models.TwoFactorChallenge) error {
+	result := DB.Model(&models.TwoFactorChallenge{}).
+		Where("id = ? AND used_at IS NULL", challenge.ID).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTwoFactorChallenge
+	}
+	return nil
+}
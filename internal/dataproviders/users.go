@@ -2,8 +2,6 @@ package dataprovider
 
 import (
 	// "fmt"
-	"time"
-
 	"github.com/hyphenXY/Streak-App/internal/models"
 	"gorm.io/gorm"
 )
@@ -13,108 +11,35 @@ func CreateUser(user *models.User) error {
 	return result.Error
 }
 
-func UpdateUserRefreshToken(userID uint, refreshToken string, refreshTokenExpiry time.Time) error {
-	result := DB.Model(&models.User{}).
-		Where("id = ?", userID).
-		Updates(map[string]interface{}{
-			"RefreshToken":       refreshToken,
-			"RefreshTokenExpiry": refreshTokenExpiry,
-		})
-	return result.Error
+// UpdateProfile updates the name and email of a user. See updateProfile.
+func UpdateProfile(req map[string]interface{}, userId uint) error {
+	return updateProfile("user", req, userId)
 }
 
-func StoreOTP(phone uint, otp string) error {
-	otpRecord := models.OTPs{
-		Phone:      phone,
-		OTP:        otp,
-		Expiry:     time.Now().Add(10 * time.Minute),
-		IsVerified: false,
-		CreatedAt:  time.Now(),
-		UpdatedAt:  time.Now(),
-	}
-	result := DB.Create(&otpRecord)
-	return result.Error
+func UserExistsByPhone(phone string) (bool, error) {
+	var count int64
+	err := DB.Model(&models.User{}).Where("phone = ?", phone).Count(&count).Error
+	return count > 0, err
 }
 
-func VerifyOTP(phone uint, otp string) (string, error) {
-	var otpRecord models.OTPs
-	err := DB.Where("phone = ? AND otp = ?", phone, otp).Last(&otpRecord).Error
-	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return "Wrong!", nil // OTP not found
-		}
-		return "Failed!", err // Other error
-	}
-
-	// Check if OTP was created within 10 minutes
-	if time.Since(otpRecord.CreatedAt) > 10*time.Minute {
-		return "Expired!", nil // OTP expired
+// ResetUserPassword sets a new password hash on the account registered to
+// phone and revokes all of its sessions.
+func ResetUserPassword(tx *gorm.DB, phone string, passwordHash string) error {
+	var user models.User
+	if err := tx.Where("phone = ?", phone).First(&user).Error; err != nil {
+		return err
 	}
-
-	// Optionally, you can delete the OTP after verification
-	// DB.Delete(&otpRecord)
-
-	return "Verified!", nil // OTP verified successfully
-}
-
-func IsPhoneVerified(phone uint) (bool, error) {
-	var otp models.OTPs
-	err := DB.Where("phone = ? AND is_verified = ?", phone, true).First(&otp).Error
-	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return false, nil // Phone not verified
-		}
-		return false, err // Other error
+	if err := tx.Model(&user).Update("password", passwordHash).Error; err != nil {
+		return err
 	}
-	return true, nil
-}
-
-func MarkPhoneVerified(phone uint) error {
-	result := DB.Model(&models.OTPs{}).
-		Where("phone = ?", phone).
-		Update("is_verified", true)
-	return result.Error
+	return RevokeAllSessions(tx, user.ID, "user")
 }
 
-func RevokeUserRefreshToken(refreshToken string) error {
-	result := DB.Model(&models.User{}).
-		Where("refresh_token = ?", refreshToken).
-		Updates(map[string]interface{}{
-			"refresh_token":        nil,
-			"refresh_token_expiry": nil,
-		})
-	return result.Error
-}
-
-func UpdateProfile(req map[string]interface{}, userId uint) error {
-	updates := map[string]interface{}{}
-
-	if req["first_name"] != "" {
-		updates["FirstName"] = req["first_name"]
-	}
-	if req["last_name"] != "" {
-		updates["LastName"] = req["last_name"]
-	}
-	if req["Email"] != "" {
-		updates["Email"] = req["Email"]
-	}
-
-	// Always update UpdatedAt
-	updates["UpdatedAt"] = time.Now()
-
-	if len(updates) == 0 {
-		return nil
-	}
-
-	result := DB.Model(&models.User{}).
-		Where("id = ?", userId).
-		Updates(updates)
-
-	if result.Error != nil {
-		return result.Error
-	}
-	if result.RowsAffected == 0 {
-		return gorm.ErrRecordNotFound
+// GetUserByPhone loads the user registered to phone.
+func GetUserByPhone(tx *gorm.DB, phone string) (*models.User, error) {
+	var user models.User
+	if err := tx.Where("phone = ?", phone).First(&user).Error; err != nil {
+		return nil, err
 	}
-	return nil
+	return &user, nil
 }
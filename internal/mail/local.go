@@ -0,0 +1,37 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// LocalSender is for development: it writes mail to the server log, or
+// appends it to Path when set, instead of sending it.
+type LocalSender struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+func (s *LocalSender) Send(ctx context.Context, to string, subject string, body string) error {
+	if s.Path == "" {
+		log.Printf("📧 Mail to %s: %s\n%s", to, subject, body)
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintf(f, "%s To: %s\nSubject: %s\n\n%s\n\n", time.Now().Format(time.RFC3339), to, subject, body)
+	return err
+}
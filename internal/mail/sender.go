@@ -0,0 +1,78 @@
+// Package mail sends transactional email such as verification codes.
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Sender delivers a plain-text email.
+type Sender interface {
+	Send(ctx context.Context, to string, subject string, body string) error
+}
+
+// NewSenderFromEnv builds the Sender selected by MAIL_PROVIDER:
+//
+//	smtp (default)  SMTP_HOST, SMTP_PORT (default 587), SMTP_USERNAME,
+//	                SMTP_PASSWORD, MAIL_FROM
+//	log             writes mail to the server log
+//	file            appends mail to MAIL_FILE_PATH (default mail.log)
+//
+// Mail carries sign-in codes, so writing it locally has to be asked for.
+func NewSenderFromEnv() (Sender, error) {
+	switch provider := strings.ToLower(os.Getenv("MAIL_PROVIDER")); provider {
+	case "log":
+		return &LocalSender{}, nil
+	case "file":
+		path := os.Getenv("MAIL_FILE_PATH")
+		if path == "" {
+			path = "mail.log"
+		}
+		return &LocalSender{Path: path}, nil
+	case "", "smtp":
+		host := os.Getenv("SMTP_HOST")
+		from := os.Getenv("MAIL_FROM")
+		if host == "" || from == "" {
+			return nil, fmt.Errorf("smtp mail provider needs SMTP_HOST and MAIL_FROM")
+		}
+		port := os.Getenv("SMTP_PORT")
+		if port == "" {
+			port = "587"
+		}
+		return &SMTPSender{
+			Host:     host,
+			Port:     port,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     from,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown MAIL_PROVIDER %q", provider)
+	}
+}
+
+var sender Sender
+
+// Init selects the mail sender from the environment. It must be called once
+// at startup before Send is used.
+func Init() error {
+	s, err := NewSenderFromEnv()
+	if err != nil {
+		return err
+	}
+	if _, ok := s.(*LocalSender); ok && gin.Mode() == gin.ReleaseMode {
+		log.Println("⚠️  MAIL_PROVIDER writes mail locally; do not use it in production")
+	}
+	sender = s
+	return nil
+}
+
+// Send delivers an email through the configured Sender.
+func Send(ctx context.Context, to string, subject string, body string) error {
+	return sender.Send(ctx, to, subject, body)
+}
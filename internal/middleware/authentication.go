@@ -2,6 +2,7 @@ package middlewares
 
 import (
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -11,112 +12,175 @@ import (
 	"github.com/hyphenXY/Streak-App/internal/utils"
 )
 
-func AuthUserMiddleware() gin.HandlerFunc {
+const (
+	principalKey = "principal"
+	classIDKey   = "classID"
+	staffRoleKey = "staffRole"
+)
+
+// Principal is the authenticated caller of a request. Callers using a
+// personal access token have a TokenID and Scopes instead of a SessionID.
+type Principal struct {
+	ID        uint
+	Role      string
+	SessionID uint
+	TokenID   uint
+	Scopes    []string
+}
+
+// Auth requires a valid bearer access token whose role is one of roles, and
+// stores the caller as a Principal for GetPrincipal. Access tokens of revoked
+// or expired sessions and of suspended admins are turned away even while the
+// token itself is still valid. Personal access tokens are not accepted; see
+// AdminOrToken.
+func Auth(roles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		header := c.GetHeader("Authorization")
-		if header == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing Authorization header"})
-			c.Abort()
-			return
+		if authenticate(c, roles, nil) {
+			c.Next()
 		}
+	}
+}
 
-		if !strings.HasPrefix(header, "Bearer ") {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid Authorization header format"})
-			c.Abort()
-			return
+// AdminOrToken is Auth("admin") that also accepts an admin's personal access
+// token, as long as it carries at least one of scopes.
+func AdminOrToken(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if authenticate(c, []string{"admin"}, scopes) {
+			c.Next()
 		}
+	}
+}
 
-		token := strings.TrimSpace(strings.TrimPrefix(header, "Bearer "))
+// authenticate checks the bearer token and stores the Principal. It writes
+// the error response and aborts when the caller is turned away. A nil scopes
+// means personal access tokens are refused.
+func authenticate(c *gin.Context, roles []string, scopes []string) bool {
+	header := c.GetHeader("Authorization")
+	if header == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "missing Authorization header"})
+		c.Abort()
+		return false
+	}
 
-		claims, err := utils.ValidateJWT(token, "user")
-		if err != nil {
-			switch err.Error() {
-			case "token expired":
-				c.JSON(http.StatusUnauthorized, gin.H{"error": "token expired"})
-			case "forbidden: role mismatch":
-				c.JSON(http.StatusForbidden, gin.H{"error": "forbidden: role mismatch"})
-			default:
-				c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
-			}
+	if !strings.HasPrefix(header, "Bearer ") {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid Authorization header format"})
+		c.Abort()
+		return false
+	}
+
+	token := strings.TrimSpace(strings.TrimPrefix(header, "Bearer "))
+
+	var principal Principal
+	if strings.HasPrefix(token, utils.PersonalAccessTokenPrefix) {
+		if scopes == nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "personal access tokens are not accepted here"})
 			c.Abort()
-			return
+			return false
 		}
 
-		c.Set("userId", claims["userId"]) // FIXED: match your JWT claim
-		c.Next()
-	}
-}
-
-func AuthAdminMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		header := c.GetHeader("Authorization")
-		if header == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing Authorization header"})
+		pat, err := dataprovider.GetActivePersonalAccessToken(utils.HashToken(token))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
 			c.Abort()
-			return
+			return false
 		}
 
-		if !strings.HasPrefix(header, "Bearer ") {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid Authorization header format"})
+		granted := strings.Split(pat.Scopes, ",")
+		if !slices.ContainsFunc(scopes, func(s string) bool { return slices.Contains(granted, s) }) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "forbidden: token scope missing"})
 			c.Abort()
-			return
+			return false
 		}
 
-		token := strings.TrimSpace(strings.TrimPrefix(header, "Bearer "))
+		_ = dataprovider.TouchPersonalAccessToken(pat.ID)
 
-		claims, err := utils.ValidateJWT(token, "admin")
+		principal = Principal{
+			ID:      pat.AdminID,
+			Role:    "admin",
+			TokenID: pat.ID,
+			Scopes:  granted,
+		}
+	} else {
+		claims, err := utils.ParseAccessToken(token)
 		if err != nil {
-			switch err.Error() {
-			case "token expired":
-				c.JSON(http.StatusUnauthorized, gin.H{"error": "token expired"})
-			case "forbidden: role mismatch":
-				c.JSON(http.StatusForbidden, gin.H{"error": "forbidden: role mismatch"})
-			default:
-				c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
-			}
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 			c.Abort()
-			return
+			return false
 		}
 
-		c.Set("userId", claims["userId"]) // FIXED: match your JWT claim
-		c.Next()
-	}
-}
-
-func AuthRootMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		header := c.GetHeader("Authorization")
-		if header == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing Authorization header"})
-			c.Abort()
-			return
+		principal = Principal{
+			ID:        claims.UserID,
+			Role:      claims.Role,
+			SessionID: claims.SessionID,
 		}
 
-		if !strings.HasPrefix(header, "Bearer ") {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid Authorization header format"})
-			c.Abort()
-			return
+		if principal.SessionID != 0 {
+			active, err := dataprovider.IsSessionActive(principal.SessionID, principal.ID, principal.Role)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check session"})
+				c.Abort()
+				return false
+			}
+			if !active {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "session revoked"})
+				c.Abort()
+				return false
+			}
 		}
+	}
 
-		token := strings.TrimSpace(strings.TrimPrefix(header, "Bearer "))
+	if !slices.Contains(roles, principal.Role) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden: role mismatch"})
+		c.Abort()
+		return false
+	}
 
-		claims, err := utils.ValidateJWT(token, "root")
+	if principal.Role == "admin" {
+		suspended, err := dataprovider.IsAdminSuspended(principal.ID)
 		if err != nil {
-			switch err.Error() {
-			case "token expired":
-				c.JSON(http.StatusUnauthorized, gin.H{"error": "token expired"})
-			case "forbidden: role mismatch":
-				c.JSON(http.StatusForbidden, gin.H{"error": "forbidden: role mismatch"})
-			default:
-				c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
-			}
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
 			c.Abort()
-			return
+			return false
+		}
+		if suspended {
+			c.JSON(http.StatusForbidden, gin.H{"error": "admin account suspended"})
+			c.Abort()
+			return false
 		}
+	}
 
-		c.Set("userId", claims["userId"]) // FIXED: match your JWT claim
-		c.Next()
+	c.Set(principalKey, principal)
+	return true
+}
+
+// GetPrincipal returns the caller stored by Auth.
+func GetPrincipal(c *gin.Context) (Principal, bool) {
+	p, ok := c.Get(principalKey)
+	if !ok {
+		return Principal{}, false
 	}
+	principal, ok := p.(Principal)
+	return principal, ok
+}
+
+// GetClassID returns the class checked by IsUserClass or IsAdminClass.
+func GetClassID(c *gin.Context) (uint, bool) {
+	id, ok := c.Get(classIDKey)
+	if !ok {
+		return 0, false
+	}
+	classID, ok := id.(uint)
+	return classID, ok
+}
+
+// GetStaffRole returns the caller's staff role checked by IsAdminClass.
+func GetStaffRole(c *gin.Context) (string, bool) {
+	role, ok := c.Get(staffRoleKey)
+	if !ok {
+		return "", false
+	}
+	staffRole, ok := role.(string)
+	return staffRole, ok
 }
 
 func IsUserClass() gin.HandlerFunc {
@@ -139,13 +203,13 @@ func IsUserClass() gin.HandlerFunc {
 			c.Abort()
 			return
 		}
-		userIDVal, exists := c.Get("userId")
+		principal, exists := GetPrincipal(c)
 		if !exists {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
 			c.Abort()
 			return
 		}
-		isEnrolled, err := dataprovider.IfAlreadyEnrolled(uint(userIDVal.(float64)), uint(classIDUint), &models.User_Classes{})
+		isEnrolled, err := dataprovider.IfAlreadyEnrolled(principal.ID, uint(classIDUint), &models.User_Classes{})
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check enrollment status"})
 			c.Abort()
@@ -156,12 +220,15 @@ func IsUserClass() gin.HandlerFunc {
 			c.Abort()
 			return
 		}
-		c.Set("classID", uint(classIDUint))
+		c.Set(classIDKey, uint(classIDUint))
 		c.Next()
 	}
 }
 
-func IsAdminClass() gin.HandlerFunc {
+// IsAdminClass requires the admin to be accepted staff of the :classId class
+// with a role granting every one of permissions. With no permissions any
+// staff role will do.
+func IsAdminClass(permissions ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		classID := c.Param("classId")
 		classIDUint, err := strconv.ParseUint(classID, 10, 64)
@@ -181,24 +248,57 @@ func IsAdminClass() gin.HandlerFunc {
 			c.Abort()
 			return
 		}
-		userIDVal, exists := c.Get("userId")
+		principal, exists := GetPrincipal(c)
 		if !exists {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
 			c.Abort()
 			return
 		}
-		isAdmin, err := dataprovider.IsUserAdmin(uint(userIDVal.(float64)), uint(classIDUint))
+		staffRole, err := dataprovider.GetStaffRole(principal.ID, uint(classIDUint))
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check admin status"})
 			c.Abort()
 			return
 		}
-		if !isAdmin {
+		if staffRole == "" {
 			c.JSON(http.StatusForbidden, gin.H{"error": "User is not an admin of this class"})
 			c.Abort()
 			return
 		}
-		c.Set("classID", uint(classIDUint))
+		for _, permission := range permissions {
+			if !models.StaffRoleHas(staffRole, permission) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Your role in this class doesn't allow this", "role": staffRole})
+				c.Abort()
+				return
+			}
+		}
+		c.Set(classIDKey, uint(classIDUint))
+		c.Set(staffRoleKey, staffRole)
+		c.Next()
+	}
+}
+
+// ClassNotArchived turns away writes to an archived class. It must run after
+// IsUserClass or IsAdminClass.
+func ClassNotArchived() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		classID, ok := GetClassID(c)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "classID not provided"})
+			c.Abort()
+			return
+		}
+		archived, err := dataprovider.IsClassArchived(classID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check class"})
+			c.Abort()
+			return
+		}
+		if archived {
+			c.JSON(http.StatusConflict, gin.H{"error": "Class is archived and read-only"})
+			c.Abort()
+			return
+		}
 		c.Next()
 	}
 }
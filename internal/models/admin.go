@@ -3,16 +3,20 @@ package models
 import "time"
 
 type Admin struct {
-	ID                 uint       `gorm:"primaryKey;autoIncrement"`
-	FirstName          string     `gorm:"size:50;"`
-	LastName           string     `gorm:"size:50;"`
-	Email              string     `gorm:"size:100;"`
-	Phone              string     `gorm:"size:10;"`
-	UserName           string     `gorm:"size:50;"`
-	Password           string     `gorm:""`
-	DOB                time.Time  `gorm:""`
-	RefreshToken       *string    `gorm:"size:255"`
-	RefreshTokenExpiry *time.Time `gorm:""`
-	CreatedAt          time.Time
-	UpdatedAt          time.Time
+	ID              uint       `gorm:"primaryKey;autoIncrement"`
+	FirstName       string     `gorm:"size:50;"`
+	LastName        string     `gorm:"size:50;"`
+	Email           string     `gorm:"size:100;"`
+	EmailVerifiedAt *time.Time `gorm:""`
+	// Phone is unique. InitDB adds the index after moving existing phones
+	// to E.164 and checking them for duplicates.
+	Phone string `gorm:"size:16;"`
+	// OTPChannel is where login and password reset codes are delivered.
+	OTPChannel  string     `gorm:"type:ENUM('sms', 'email');default:'sms'"`
+	UserName    string     `gorm:"size:50;"`
+	Password    string     `gorm:""`
+	DOB         time.Time  `gorm:""`
+	SuspendedAt *time.Time `gorm:""`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
 }
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+const (
+	AuthEventRefreshTokenReuse = "refresh_token_reuse"
+	AuthEventSignInSuccess     = "sign_in_success"
+	AuthEventSignInFailure     = "sign_in_failure"
+	// AuthEventSignInBlocked is an attempt turned away by the lockout before
+	// the password was checked. It doesn't count as a failure.
+	AuthEventSignInBlocked  = "sign_in_blocked"
+	AuthEventPasswordChange = "password_change"
+	AuthEventPhoneChange    = "phone_change"
+)
+
+// AuthEvent is an append-only record of security-relevant auth activity.
+type AuthEvent struct {
+	ID        uint   `gorm:"primaryKey;autoIncrement"`
+	Type      string `gorm:"size:40;index"`
+	Role      string `gorm:"size:10;index:idx_auth_events_subject"`
+	SubjectID uint   `gorm:"index:idx_auth_events_subject"`
+	// LockoutKey is the hash of what a sign-in attempt named (a username,
+	// phone or account), which the sign-in lockout counts failures by.
+	LockoutKey string    `gorm:"size:64;index"`
+	SessionID  *uint     `gorm:""`
+	IP         string    `gorm:"size:45;index"`
+	UserAgent  string    `gorm:"size:255;"`
+	Details    string    `gorm:"size:255;"`
+	CreatedAt  time.Time `gorm:"index"`
+}
+
+// SignInLock is a row per lockout key that sign-in attempts lock while they
+// count earlier failures and record their own, so parallel attempts on the
+// same key are counted one after another.
+type SignInLock struct {
+	Role       string `gorm:"primaryKey;size:10"`
+	LockoutKey string `gorm:"primaryKey;size:64"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// ClassCode is a code students can enroll with. A class can have several,
+// for example one per section, each with its own expiry and use limit.
+// Classes.ClassCode mirrors the code issued when the class was created, for
+// admin clients that only show one. It is never shown to students.
+type ClassCode struct {
+	ID               uint       `gorm:"primaryKey;autoIncrement"`
+	ClassID          uint       `gorm:"index"`
+	Code             string     `gorm:"size:10;uniqueIndex"`
+	Label            string     `gorm:"size:50;"`
+	ExpiresAt        *time.Time `gorm:""`
+	MaxUses          *int       `gorm:""` // nil means unlimited
+	Uses             int        `gorm:"default:0"`
+	RevokedAt        *time.Time `gorm:""`
+	CreatedByAdminID uint       `gorm:""`
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
+// Active reports whether the code can still be used to enroll.
+func (c *ClassCode) Active(now time.Time) bool {
+	return c.RevokedAt == nil &&
+		(c.ExpiresAt == nil || c.ExpiresAt.After(now)) &&
+		(c.MaxUses == nil || c.Uses < *c.MaxUses)
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+const (
+	ClassEventTransferProposed  = "transfer_proposed"
+	ClassEventTransferAccepted  = "transfer_accepted"
+	ClassEventTransferDeclined  = "transfer_declined"
+	ClassEventTransferCancelled = "transfer_cancelled"
+	ClassEventOwnerReassigned   = "owner_reassigned"
+	ClassEventUpdated           = "updated"
+	ClassEventArchived          = "archived"
+	ClassEventRestored          = "restored"
+	ClassEventDeleted           = "deleted"
+	ClassEventCodeCreated       = "code_created"
+	ClassEventCodeRotated       = "code_rotated"
+	ClassEventCodeRevoked       = "code_revoked"
+	ClassEventInviteIssued      = "invite_issued"
+	ClassEventInvitesRevoked    = "invites_revoked"
+	ClassEventStudentLeft       = "student_left"
+	ClassEventStudentRemoved    = "student_removed"
+)
+
+// ClassEvent is an append-only audit record of changes to a class.
+type ClassEvent struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement"`
+	ClassID   uint      `gorm:"index"`
+	Type      string    `gorm:"size:40;index"`
+	ActorID   uint      `gorm:""`
+	ActorRole string    `gorm:"size:10;"`
+	Details   string    `gorm:"size:255;"`
+	CreatedAt time.Time `gorm:"index"`
+}
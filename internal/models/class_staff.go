@@ -0,0 +1,59 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// Staff roles within a class.
+const (
+	StaffRoleOwner     = "owner"
+	StaffRoleCoTeacher = "co_teacher"
+	StaffRoleAssistant = "assistant"
+)
+
+// Things a staff member can do in a class.
+const (
+	PermViewAttendance = "view_attendance"
+	PermMarkAttendance = "mark_attendance"
+	PermViewRoster     = "view_roster"
+	PermManageRoster   = "manage_roster"
+	PermManageClass    = "manage_class"
+	PermManageStaff    = "manage_staff"
+	PermTransferClass  = "transfer_class"
+	PermDeleteClass    = "delete_class"
+)
+
+// StaffPermissions is the permission set granted by each staff role.
+var StaffPermissions = map[string][]string{
+	StaffRoleOwner: {
+		PermViewAttendance, PermMarkAttendance, PermViewRoster,
+		PermManageRoster, PermManageClass, PermManageStaff,
+		PermTransferClass, PermDeleteClass,
+	},
+	StaffRoleCoTeacher: {
+		PermViewAttendance, PermMarkAttendance, PermViewRoster,
+		PermManageRoster, PermManageClass,
+	},
+	StaffRoleAssistant: {
+		PermViewAttendance, PermMarkAttendance, PermViewRoster,
+	},
+}
+
+// StaffRoleHas reports whether role grants permission.
+func StaffRoleHas(role string, permission string) bool {
+	return slices.Contains(StaffPermissions[role], permission)
+}
+
+// ClassStaff links an admin to a class they teach. Invited staff have no
+// AcceptedAt until they accept, and get no access before that.
+type ClassStaff struct {
+	ID               uint       `gorm:"primaryKey;autoIncrement"`
+	ClassID          uint       `gorm:"uniqueIndex:idx_class_staff_admin"`
+	AdminID          uint       `gorm:"uniqueIndex:idx_class_staff_admin;index"`
+	Role             string     `gorm:"type:ENUM('owner', 'co_teacher', 'assistant');not null"`
+	InvitedByAdminID uint       `gorm:""`
+	AcceptedAt       *time.Time `gorm:""`
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+const (
+	ClassTransferPending   = "pending"
+	ClassTransferAccepted  = "accepted"
+	ClassTransferDeclined  = "declined"
+	ClassTransferCancelled = "cancelled"
+)
+
+// ClassTransfer is a proposal to hand a class over to another admin. It only
+// takes effect once ToAdminID accepts it.
+type ClassTransfer struct {
+	ID             uint       `gorm:"primaryKey;autoIncrement"`
+	ClassID        uint       `gorm:"index"`
+	FromAdminID    uint       `gorm:""`
+	ToAdminID      uint       `gorm:"index"`
+	ProposedByID   uint       `gorm:""`
+	ProposedByRole string     `gorm:"size:10;"`
+	Note           string     `gorm:"size:255;"`
+	Status         string     `gorm:"type:ENUM('pending', 'accepted', 'declined', 'cancelled');default:'pending';index"`
+	RespondedAt    *time.Time `gorm:""`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
@@ -6,9 +6,16 @@ type Classes struct {
 	ID               uint   `gorm:"primaryKey;autoIncrement"`
 	Name             string `gorm:"size:50;"`
 	Email            string `gorm:"size:100;"`
-	Phone            string `gorm:"size:10;"`
+	Phone            string `gorm:"size:16;"`
 	CreatedByAdminId uint   `gorm:"size:50;"`
 	ClassCode        string `gorm:"size:10;uniqueIndex"`
-	CreatedAt        time.Time
-	UpdatedAt        time.Time
+	// RequireApproval turns enrollments into requests an admin has to approve.
+	RequireApproval bool `gorm:"default:false"`
+	// ArchivedAt makes the class read-only and hides it from class lists.
+	ArchivedAt *time.Time `gorm:"index"`
+	// InviteEpoch is stamped into class invites; bumping it voids every
+	// invite issued before.
+	InviteEpoch uint `gorm:"default:0"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
 }
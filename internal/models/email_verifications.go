@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// Where account OTPs can be delivered (User.OTPChannel, Admin.OTPChannel).
+const (
+	OTPChannelSMS   = "sms"
+	OTPChannelEmail = "email"
+)
+
+// EmailVerification is a code mailed to prove an account owns Email. Only the
+// SHA-256 of the code is stored.
+type EmailVerification struct {
+	ID        uint       `gorm:"primaryKey;autoIncrement"`
+	SubjectID uint       `gorm:"index:idx_email_verifications_subject"`
+	Role      string     `gorm:"type:ENUM('user', 'admin', 'root');index:idx_email_verifications_subject"`
+	Email     string     `gorm:"size:100;"`
+	CodeHash  string     `gorm:"size:64;"`
+	Expiry    time.Time  `gorm:""`
+	Attempts  int        `gorm:"default:0"`
+	UsedAt    *time.Time `gorm:""`
+	CreatedAt time.Time
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+const (
+	EnrollmentRequestPending  = "pending"
+	EnrollmentRequestApproved = "approved"
+	EnrollmentRequestRejected = "rejected"
+)
+
+// EnrollmentRequest is a student's request to join a class that requires
+// approval. The student is only enrolled once an admin approves it.
+type EnrollmentRequest struct {
+	ID                uint       `gorm:"primaryKey;autoIncrement"`
+	ClassID           uint       `gorm:"index:idx_enrollment_requests_class"`
+	UserID            uint       `gorm:"index"`
+	ClassCodeID       uint       `gorm:""`
+	Status            string     `gorm:"type:ENUM('pending', 'approved', 'rejected');default:'pending';index:idx_enrollment_requests_class"`
+	Reason            string     `gorm:"size:255;"`
+	ReviewedByAdminID *uint      `gorm:""`
+	ReviewedAt        *time.Time `gorm:""`
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
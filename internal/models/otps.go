@@ -2,12 +2,35 @@ package models
 
 import "time"
 
+// What an OTP was issued for. A code or ticket issued for one purpose can't
+// be redeemed for another.
+const (
+	OTPPurposeSignup        = "signup"
+	OTPPurposeLogin         = "login"
+	OTPPurposePasswordReset = "password_reset"
+	OTPPurposePhoneChange   = "phone_change"
+)
+
+// OTPs are keyed by the E.164 phone they were sent to and the role of the
+// account they were sent for; a user's code or ticket is no good to an admin.
 type OTPs struct {
-	ID         uint   `gorm:"primaryKey;autoIncrement"`
-	Phone      uint   `gorm:""`
-	OTP        string `gorm:"size:6;"`
-	IsVerified bool   `gorm:"default:false"`
-	Expiry     time.Time
-	CreatedAt  time.Time
-	UpdatedAt  time.Time
+	ID      uint   `gorm:"primaryKey;autoIncrement"`
+	Phone   string `gorm:"size:16;index:idx_otps_phone_purpose"`
+	Role    string `gorm:"type:ENUM('user', 'admin', 'root');index:idx_otps_phone_purpose"`
+	Purpose string `gorm:"size:20;index:idx_otps_phone_purpose"`
+	// Only the SHA-256 of the code is kept.
+	CodeHash   string     `gorm:"size:64;"`
+	Expiry     time.Time  `gorm:""`
+	ConsumedAt *time.Time `gorm:""`
+	// Send and verify limits are enforced from these columns so every server
+	// instance sees the same counters.
+	RequestIP string     `gorm:"size:45;index"`
+	Attempts  int        `gorm:"default:0"`
+	LockedAt  *time.Time `gorm:""`
+	// Set once the OTP is consumed; only the SHA-256 of the ticket is kept.
+	TicketHash   *string    `gorm:"size:64;uniqueIndex"`
+	TicketExpiry *time.Time `gorm:""`
+	TicketUsedAt *time.Time `gorm:""`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
 }
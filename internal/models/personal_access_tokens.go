@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// What a personal access token may do. Tokens never get more than the admin
+// who made them, and only reach endpoints that accept one of their scopes.
+const (
+	PATScopeAttendanceRead = "attendance:read"
+	PATScopeRosterRead     = "roster:read"
+	PATScopeRosterManage   = "roster:manage"
+)
+
+// PATScopes lists every valid scope.
+var PATScopes = []string{PATScopeAttendanceRead, PATScopeRosterRead, PATScopeRosterManage}
+
+// PersonalAccessToken lets an admin's scripts call the API without signing
+// in. Only the SHA-256 of the token is stored; Prefix is kept so the admin
+// can tell tokens apart.
+type PersonalAccessToken struct {
+	ID         uint       `gorm:"primaryKey;autoIncrement"`
+	AdminID    uint       `gorm:"index"`
+	Name       string     `gorm:"size:100;"`
+	Prefix     string     `gorm:"size:16;"`
+	TokenHash  string     `gorm:"size:64;uniqueIndex"`
+	Scopes     string     `gorm:"size:255;"` // comma separated
+	ExpiresAt  *time.Time `gorm:""`
+	LastUsedAt *time.Time `gorm:""`
+	RevokedAt  *time.Time `gorm:""`
+	CreatedAt  time.Time
+}
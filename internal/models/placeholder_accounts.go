@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// PlaceholderAccount stands in for a student an admin imported before they
+// had an account. When someone signs up with the phone, or verifies the
+// email, they claim it and are enrolled in its classes.
+type PlaceholderAccount struct {
+	ID               uint       `gorm:"primaryKey;autoIncrement"`
+	Email            string     `gorm:"size:100;index"`
+	Phone            string     `gorm:"size:16;index"`
+	CreatedByAdminID uint       `gorm:""`
+	ClaimedByUserID  *uint      `gorm:""`
+	ClaimedAt        *time.Time `gorm:""`
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
+// PlaceholderEnrollment is a class a placeholder account will be enrolled in
+// once claimed.
+type PlaceholderEnrollment struct {
+	ID                   uint `gorm:"primaryKey;autoIncrement"`
+	PlaceholderAccountID uint `gorm:"uniqueIndex:idx_placeholder_enrollment"`
+	ClassID              uint `gorm:"uniqueIndex:idx_placeholder_enrollment;index"`
+	InvitedByAdminID     uint `gorm:""`
+	CreatedAt            time.Time
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// RefreshToken is one link in a session's rotation chain. The session is the
+// token family: every refresh rotates to a new token, and presenting a token
+// that was already rotated revokes the whole session.
+type RefreshToken struct {
+	ID        uint       `gorm:"primaryKey;autoIncrement"`
+	SessionID uint       `gorm:"index"`
+	TokenHash string     `gorm:"size:64;uniqueIndex"`
+	Expiry    time.Time  `gorm:""`
+	RotatedAt *time.Time `gorm:""`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
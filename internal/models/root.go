@@ -3,11 +3,13 @@ package models
 import "time"
 
 type Root struct {
-	ID        uint      `gorm:"primaryKey;autoIncrement"`
-	FirstName string    `gorm:"size:50;"`
-	LastName  string    `gorm:"size:50;"`
-	Email     string    `gorm:"size:100;"`
-	Phone     string    `gorm:"size:10;"`
+	ID        uint   `gorm:"primaryKey;autoIncrement"`
+	FirstName string `gorm:"size:50;"`
+	LastName  string `gorm:"size:50;"`
+	Email     string `gorm:"size:100;"`
+	// Phone is unique. InitDB adds the index after moving existing phones
+	// to E.164 and checking them for duplicates.
+	Phone     string    `gorm:"size:16;"`
 	UserName  string    `gorm:"size:50;"`
 	Password  string    `gorm:""`
 	DOB       time.Time `gorm:""`
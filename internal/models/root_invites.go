@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// RootInvite lets an existing root allow one more root to register.
+type RootInvite struct {
+	ID              uint       `gorm:"primaryKey;autoIncrement"`
+	Email           string     `gorm:"size:100;"`
+	TokenHash       string     `gorm:"size:64;uniqueIndex"`
+	InvitedByRootId uint       `gorm:""`
+	Expiry          time.Time  `gorm:""`
+	UsedAt          *time.Time `gorm:""`
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// Session is one signed-in device. Each session has its own chain of refresh
+// tokens (see RefreshToken), so signing in on a new device doesn't log out the
+// others.
+type Session struct {
+	ID         uint       `gorm:"primaryKey;autoIncrement"`
+	SubjectID  uint       `gorm:"index:idx_sessions_subject"`
+	Role       string     `gorm:"type:ENUM('user', 'admin', 'root');index:idx_sessions_subject"`
+	Expiry     time.Time  `gorm:""`
+	DeviceName string     `gorm:"size:100;"`
+	IP         string     `gorm:"size:45;"`
+	UserAgent  string     `gorm:"size:255;"`
+	LastUsedAt time.Time  `gorm:""`
+	RevokedAt  *time.Time `gorm:""`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// Keys of the Setting rows root can change.
+const (
+	SettingRequireAdmin2FA = "require_admin_2fa"
+)
+
+// Setting is a system-wide option managed by root.
+type Setting struct {
+	Key       string `gorm:"primaryKey;size:50"`
+	Value     string `gorm:"size:255;"`
+	UpdatedAt time.Time
+}
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// TwoFactor is the TOTP (RFC 6238) enrollment of an admin or root. It is
+// created when setup starts and only counts once EnabledAt is set.
+type TwoFactor struct {
+	ID        uint       `gorm:"primaryKey;autoIncrement"`
+	SubjectID uint       `gorm:"uniqueIndex:idx_two_factors_subject"`
+	Role      string     `gorm:"type:ENUM('user', 'admin', 'root');uniqueIndex:idx_two_factors_subject"`
+	Secret    string     `gorm:"size:64;"`
+	EnabledAt *time.Time `gorm:""`
+	// LastUsedStep is the TOTP time step of the last accepted code, so the
+	// same code can't be replayed within its validity window.
+	LastUsedStep int64 `gorm:"default:0"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// RecoveryCode is a single-use fallback for a lost authenticator. Only the
+// SHA-256 of the code is stored.
+type RecoveryCode struct {
+	ID        uint       `gorm:"primaryKey;autoIncrement"`
+	SubjectID uint       `gorm:"index:idx_recovery_codes_subject"`
+	Role      string     `gorm:"type:ENUM('user', 'admin', 'root');index:idx_recovery_codes_subject"`
+	CodeHash  string     `gorm:"size:64;uniqueIndex"`
+	UsedAt    *time.Time `gorm:""`
+	CreatedAt time.Time
+}
+
+// TwoFactorChallenge is the second step of a sign-in whose password was
+// correct. The client gets the token and trades it, with a TOTP or recovery
+// code, for a session.
+type TwoFactorChallenge struct {
+	ID         uint       `gorm:"primaryKey;autoIncrement"`
+	SubjectID  uint       `gorm:""`
+	Role       string     `gorm:"type:ENUM('user', 'admin', 'root');"`
+	TokenHash  string     `gorm:"size:64;uniqueIndex"`
+	DeviceName string     `gorm:"size:100;"`
+	Expiry     time.Time  `gorm:""`
+	Attempts   int        `gorm:"default:0"`
+	UsedAt     *time.Time `gorm:""`
+	CreatedAt  time.Time
+}
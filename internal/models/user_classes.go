@@ -2,10 +2,17 @@ package models
 
 import "time"
 
+// User_Classes has one row per user and class, enforced by idx_user_class.
+// InitDB adds that index after merging the duplicates older versions could
+// leave behind.
 type User_Classes struct {
-	ID        uint      `gorm:"primaryKey;autoIncrement"`
-	UserID    uint      `gorm:""`
-	ClassID   uint      `gorm:""`
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID      uint `gorm:"primaryKey;autoIncrement"`
+	UserID  uint `gorm:""`
+	ClassID uint `gorm:""`
+	// InactiveAt is set when the student leaves or is removed. The row and
+	// the attendance are kept, and enrolling again clears it.
+	InactiveAt       *time.Time `gorm:"index"`
+	RemovedByAdminID *uint      `gorm:""`
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
 }
@@ -0,0 +1,50 @@
+package otp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const fazpassSendURL = "https://api.fazpass.com/v1/otp/send"
+
+// FazpassSender sends codes through the Fazpass OTP gateway.
+type FazpassSender struct {
+	URL         string
+	MerchantKey string
+	GatewayKey  string
+	Client      *http.Client
+}
+
+func (s *FazpassSender) Send(ctx context.Context, phone string, code string) error {
+	payload, err := json.Marshal(map[string]string{
+		"phone":       phone,
+		"otp":         code,
+		"gateway_key": s.GatewayKey,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+s.MerchantKey)
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("fazpass responded %s: %s", resp.Status, body)
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package otp
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// LocalSender is for development: it writes codes to the server log, or
+// appends them to Path when set, instead of sending an SMS.
+type LocalSender struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+func (s *LocalSender) Send(ctx context.Context, phone string, code string) error {
+	if s.Path == "" {
+		log.Printf("📨 OTP for %s: %s", phone, code)
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintf(f, "%s %s %s\n", time.Now().Format(time.RFC3339), phone, code)
+	return err
+}
@@ -0,0 +1,60 @@
+package otp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Sender delivers a one-time code to a phone number in E.164 form.
+type Sender interface {
+	Send(ctx context.Context, phone string, code string) error
+}
+
+// NewSenderFromEnv builds the Sender selected by OTP_PROVIDER:
+//
+//	fazpass (default)  FAZPASS_MERCHANT_KEY, FAZPASS_GATEWAY_KEY
+//	log                writes codes to the server log
+//	file               appends codes to OTP_FILE_PATH (default otp.log)
+//	webhook            POSTs JSON to OTP_WEBHOOK_URL, optional OTP_WEBHOOK_TOKEN
+func NewSenderFromEnv() (Sender, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	switch provider := strings.ToLower(os.Getenv("OTP_PROVIDER")); provider {
+	case "", "fazpass":
+		merchantKey := os.Getenv("FAZPASS_MERCHANT_KEY")
+		gatewayKey := os.Getenv("FAZPASS_GATEWAY_KEY")
+		if merchantKey == "" || gatewayKey == "" {
+			return nil, fmt.Errorf("fazpass OTP provider needs FAZPASS_MERCHANT_KEY and FAZPASS_GATEWAY_KEY")
+		}
+		return &FazpassSender{
+			URL:         fazpassSendURL,
+			MerchantKey: merchantKey,
+			GatewayKey:  gatewayKey,
+			Client:      client,
+		}, nil
+	case "log":
+		return &LocalSender{}, nil
+	case "file":
+		path := os.Getenv("OTP_FILE_PATH")
+		if path == "" {
+			path = "otp.log"
+		}
+		return &LocalSender{Path: path}, nil
+	case "webhook":
+		url := os.Getenv("OTP_WEBHOOK_URL")
+		if url == "" {
+			return nil, fmt.Errorf("webhook OTP provider needs OTP_WEBHOOK_URL")
+		}
+		return &WebhookSender{
+			URL:    url,
+			Token:  os.Getenv("OTP_WEBHOOK_TOKEN"),
+			Client: client,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown OTP_PROVIDER %q", provider)
+	}
+}
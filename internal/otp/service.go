@@ -0,0 +1,227 @@
+package otp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	dataprovider "github.com/hyphenXY/Streak-App/internal/dataproviders"
+	"github.com/hyphenXY/Streak-App/internal/mail"
+	"github.com/hyphenXY/Streak-App/internal/models"
+	"github.com/hyphenXY/Streak-App/internal/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	// CodeTTL is how long a freshly sent code can be verified.
+	CodeTTL = 10 * time.Minute
+	// TicketTTL is how long the ticket returned by Verify can be redeemed.
+	TicketTTL = 15 * time.Minute
+
+	// SendCooldown is the minimum gap between two codes sent to one phone.
+	SendCooldown = time.Minute
+	// SendWindow is the period the per-phone and per-IP quotas apply to.
+	SendWindow       = time.Hour
+	MaxSendsPerPhone = 5
+	MaxSendsPerIP    = 20
+	// MaxVerifyAttempts wrong guesses lock an OTP; a new one must be sent.
+	MaxVerifyAttempts = 5
+)
+
+var (
+	ErrInvalidPhone   = utils.ErrInvalidPhone
+	ErrInvalidPurpose = errors.New("invalid OTP purpose")
+	ErrDeliveryFailed = errors.New("failed to deliver OTP")
+	ErrInvalidOTP     = errors.New("OTP not found")
+	ErrExpiredOTP     = errors.New("OTP expired")
+	ErrInvalidTicket  = dataprovider.ErrInvalidTicket
+)
+
+// RateLimitError is returned when a send quota or the verify attempt cap is
+// hit. RetryAfter says when the caller may try again.
+type RateLimitError struct {
+	Reason     string
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return e.Reason
+}
+
+// RetryAfterSeconds rounds RetryAfter up to whole seconds, as used by the
+// Retry-After header.
+func (e *RateLimitError) RetryAfterSeconds() int {
+	secs := int((e.RetryAfter + time.Second - 1) / time.Second)
+	return max(secs, 1)
+}
+
+var sender Sender
+
+// Init selects the OTP sender from the environment. It must be called once at
+// startup before Send is used.
+func Init() error {
+	s, err := NewSenderFromEnv()
+	if err != nil {
+		return err
+	}
+	if _, ok := s.(*LocalSender); ok && gin.Mode() == gin.ReleaseMode {
+		log.Println("⚠️  OTP_PROVIDER writes codes locally; do not use it in production")
+	}
+	sender = s
+	return nil
+}
+
+// ValidPurpose reports whether purpose is one of the models.OTPPurpose* values.
+func ValidPurpose(purpose string) bool {
+	switch purpose {
+	case models.OTPPurposeSignup, models.OTPPurposeLogin, models.OTPPurposePasswordReset, models.OTPPurposePhoneChange:
+		return true
+	}
+	return false
+}
+
+// Send generates a new OTP for phone and purpose, stores it and delivers it.
+// The code is bound to role and can only be verified for that role.
+// requestIP is the client asking for the code and counts towards its quota.
+func Send(ctx context.Context, role string, phone string, purpose string, requestIP string) error {
+	return send(ctx, role, phone, purpose, requestIP, func(e164 string, code string) error {
+		return sender.Send(ctx, e164, code)
+	})
+}
+
+// SendVia is Send for an existing account. With channel "email" and a
+// verified email the code is mailed there instead of texted; it is still
+// tied to phone, so Verify and Redeem work the same either way.
+func SendVia(ctx context.Context, role string, channel string, phone string, email string, purpose string, requestIP string) error {
+	if channel != models.OTPChannelEmail || email == "" {
+		return Send(ctx, role, phone, purpose, requestIP)
+	}
+	return send(ctx, role, phone, purpose, requestIP, func(_ string, code string) error {
+		body := fmt.Sprintf("Your Streak code is %s. It expires in %d minutes.\n\nIf you didn't ask for it, ignore this email.",
+			code, int(CodeTTL.Minutes()))
+		return mail.Send(ctx, email, "Your Streak code", body)
+	})
+}
+
+func send(ctx context.Context, role string, phone string, purpose string, requestIP string, deliver func(e164 string, code string) error) error {
+	e164, err := utils.NormalizePhone(phone)
+	if err != nil {
+		return err
+	}
+	if !ValidPurpose(purpose) {
+		return ErrInvalidPurpose
+	}
+
+	if err := checkSendQuota(e164, requestIP); err != nil {
+		return err
+	}
+
+	code, err := utils.GenerateOTP()
+	if err != nil {
+		return err
+	}
+
+	if err := dataprovider.StoreOTP(e164, role, purpose, utils.HashToken(code), time.Now().Add(CodeTTL), requestIP); err != nil {
+		return err
+	}
+
+	if err := deliver(e164, code); err != nil {
+		log.Printf("❌ OTP delivery to %s failed: %v", e164, err)
+		return fmt.Errorf("%w: %v", ErrDeliveryFailed, err)
+	}
+	return nil
+}
+
+// Verify consumes the latest OTP sent to phone for role and purpose. On
+// success it returns a single-use verification ticket, valid for TicketTTL,
+// that the follow-up request (e.g. SignUp) of the same role must present.
+func Verify(role string, phone string, purpose string, code string) (string, error) {
+	e164, err := utils.NormalizePhone(phone)
+	if err != nil {
+		return "", err
+	}
+	if !ValidPurpose(purpose) {
+		return "", ErrInvalidPurpose
+	}
+
+	ticket, err := utils.GenerateRefreshToken()
+	if err != nil {
+		return "", err
+	}
+
+	err = dataprovider.ConsumeOTP(e164, role, purpose, utils.HashToken(code), MaxVerifyAttempts, utils.HashToken(ticket), time.Now().Add(TicketTTL))
+	switch {
+	case errors.Is(err, dataprovider.ErrOTPNotFound), errors.Is(err, dataprovider.ErrOTPMismatch):
+		return "", ErrInvalidOTP
+	case errors.Is(err, dataprovider.ErrOTPExpired):
+		return "", ErrExpiredOTP
+	case errors.Is(err, dataprovider.ErrOTPLocked):
+		// Locked codes stay locked; the caller has to request a new one.
+		return "", &RateLimitError{
+			Reason:     "too many wrong attempts, request a new OTP",
+			RetryAfter: nextSendAllowed(e164),
+		}
+	case err != nil:
+		return "", err
+	}
+	return ticket, nil
+}
+
+// Redeem spends a verification ticket for role, phone and purpose and runs fn
+// in the same transaction. If fn fails the ticket stays usable.
+func Redeem(role string, phone string, purpose string, ticket string, fn func(tx *gorm.DB) error) error {
+	e164, err := utils.NormalizePhone(phone)
+	if err != nil {
+		return err
+	}
+	return dataprovider.RedeemVerificationTicket(e164, role, purpose, utils.HashToken(ticket), fn)
+}
+
+func checkSendQuota(phone string, requestIP string) error {
+	now := time.Now()
+	stats, err := dataprovider.GetOTPSendStats(phone, requestIP, now.Add(-SendWindow))
+	if err != nil {
+		return err
+	}
+
+	if stats.LastPhoneSend != nil && now.Sub(*stats.LastPhoneSend) < SendCooldown {
+		return &RateLimitError{
+			Reason:     "please wait before requesting another OTP",
+			RetryAfter: stats.LastPhoneSend.Add(SendCooldown).Sub(now),
+		}
+	}
+	if stats.PhoneCount >= MaxSendsPerPhone && stats.OldestPhoneSend != nil {
+		return &RateLimitError{
+			Reason:     "too many OTPs requested for this phone",
+			RetryAfter: stats.OldestPhoneSend.Add(SendWindow).Sub(now),
+		}
+	}
+	if stats.IPCount >= MaxSendsPerIP && stats.OldestIPSend != nil {
+		return &RateLimitError{
+			Reason:     "too many OTPs requested from this address",
+			RetryAfter: stats.OldestIPSend.Add(SendWindow).Sub(now),
+		}
+	}
+	return nil
+}
+
+// nextSendAllowed reports how long until phone may be sent a new code,
+// ignoring the per-IP quota.
+func nextSendAllowed(phone string) time.Duration {
+	now := time.Now()
+	stats, err := dataprovider.GetOTPSendStats(phone, "", now.Add(-SendWindow))
+	if err != nil {
+		return SendCooldown
+	}
+	wait := time.Duration(0)
+	if stats.LastPhoneSend != nil {
+		wait = stats.LastPhoneSend.Add(SendCooldown).Sub(now)
+	}
+	if stats.PhoneCount >= MaxSendsPerPhone && stats.OldestPhoneSend != nil {
+		wait = max(wait, stats.OldestPhoneSend.Add(SendWindow).Sub(now))
+	}
+	return max(wait, 0)
+}
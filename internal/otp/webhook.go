@@ -0,0 +1,47 @@
+package otp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// WebhookSender POSTs {"phone", "otp"} as JSON to an arbitrary endpoint, for
+// SMS gateways that don't have a dedicated sender.
+type WebhookSender struct {
+	URL    string
+	Token  string
+	Client *http.Client
+}
+
+func (s *WebhookSender) Send(ctx context.Context, phone string, code string) error {
+	payload, err := json.Marshal(map[string]string{
+		"phone": phone,
+		"otp":   code,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.Token)
+	}
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("otp webhook responded %s", resp.Status)
+	}
+	return nil
+}
@@ -2,35 +2,165 @@ package admin_routes
 
 import (
 	"github.com/gin-gonic/gin"
+	auth_controller "github.com/hyphenXY/Streak-App/internal/controllers/auth"
 	admin_controller "github.com/hyphenXY/Streak-App/internal/controllers/admin"
 	middlewares "github.com/hyphenXY/Streak-App/internal/middleware"
+	"github.com/hyphenXY/Streak-App/internal/models"
 )
 
 func RegisterAdminRoutes(r *gin.RouterGroup) {
 	r.POST("/signIn", admin_controller.SignIn)
+	r.POST("/signIn/otp/send", admin_controller.SendSignInOTP)
+	r.POST("/signIn/otp", admin_controller.SignInWithOTP)
+	r.POST("/signIn/2fa", admin_controller.SignInTwoFactor)
+	r.POST("/signIn/2fa/setup", admin_controller.SetupTwoFactorSignIn)
 	r.POST("/signUp", admin_controller.SignUp)
 	r.POST("/sendOTP", admin_controller.SendOTP)
 	r.POST("/verifyOTP", admin_controller.VerifyOTP)
+	r.POST("/forgotPassword", admin_controller.ForgotPassword)
+	r.POST("/forgotPassword/verify", admin_controller.VerifyResetOTP)
+	r.POST("/resetPassword", admin_controller.ResetPassword)
 	r.POST("/refreshToken", admin_controller.RefreshTokenUser)
 
 	protected := r.Group("")
-	protected.Use(middlewares.AuthAdminMiddleware())
+	protected.Use(middlewares.Auth("admin"))
 	{
-		protected.GET("/classList", admin_controller.ClassList)
 		protected.GET("/profile", admin_controller.Profile)
 		protected.PATCH("/profile", admin_controller.UpdateProfile)
 		protected.POST("/createClass", admin_controller.CreateClass)
 		protected.POST("/logOutAdmin", admin_controller.LogOutAdmin)
+		protected.GET("/sessions", auth_controller.ListSessions)
+		protected.DELETE("/sessions/:sessionId", auth_controller.RevokeSession)
+		protected.POST("/sessions/revokeOthers", auth_controller.RevokeOtherSessions)
+		protected.GET("/authEvents", auth_controller.ListAuthEvents)
+		protected.POST("/email/sendVerification", auth_controller.SendEmailVerification)
+		protected.POST("/email/verify", auth_controller.VerifyEmail)
+		protected.PUT("/otpChannel", auth_controller.SetOTPChannel)
+		protected.POST("/changePassword", auth_controller.ChangePassword)
+		protected.POST("/changePhone/sendOTP", auth_controller.SendChangePhoneOTP)
+		protected.POST("/changePhone", auth_controller.ChangePhone)
+		protected.GET("/2fa", auth_controller.TwoFactorStatus)
+		protected.POST("/2fa/setup", auth_controller.SetupTwoFactor)
+		protected.POST("/2fa/enable", auth_controller.EnableTwoFactor)
+		protected.POST("/2fa/disable", auth_controller.DisableTwoFactor)
+		protected.POST("/2fa/recoveryCodes", auth_controller.RegenerateRecoveryCodes)
+		protected.GET("/tokens", admin_controller.ListTokens)
+		protected.POST("/tokens", admin_controller.CreateToken)
+		protected.DELETE("/tokens/:tokenId", admin_controller.RevokeToken)
+		protected.GET("/staffInvites", admin_controller.ListStaffInvites)
+		protected.POST("/staffInvites/:classId/accept", admin_controller.AcceptStaffInvite)
+		protected.DELETE("/staffInvites/:classId", admin_controller.DeclineStaffInvite)
+		protected.GET("/classTransfers", admin_controller.ListClassTransfers)
+		protected.POST("/classTransfers/:transferId/accept", admin_controller.AcceptClassTransfer)
+		protected.POST("/classTransfers/:transferId/decline", admin_controller.DeclineClassTransfer)
+	}
+
+	// Routes below also take personal access tokens with a matching scope
+	tokenClasses := r.Group("")
+	tokenClasses.Use(middlewares.AdminOrToken(models.PATScopeAttendanceRead, models.PATScopeRosterRead, models.PATScopeRosterManage))
+	{
+		tokenClasses.GET("/classList", admin_controller.ClassList)
+	}
+
+	attendanceRead := r.Group("")
+	attendanceRead.Use(middlewares.AdminOrToken(models.PATScopeAttendanceRead), middlewares.IsAdminClass(models.PermViewAttendance))
+	{
+		attendanceRead.GET("/quickSummary/:classId", admin_controller.QuickSummary)
+		attendanceRead.GET("/streak/:classId", admin_controller.Streak)
+		attendanceRead.GET("/personalSummary/:classId", admin_controller.PersonalSummary)
+	}
+
+	rosterRead := r.Group("")
+	rosterRead.Use(middlewares.AdminOrToken(models.PATScopeRosterRead, models.PATScopeRosterManage), middlewares.IsAdminClass(models.PermViewRoster))
+	{
+		rosterRead.GET("/studentsList/:classId", admin_controller.StudentsList)
 	}
 	
 	protectedAdminClasses := r.Group("")
-	protectedAdminClasses.Use(middlewares.AuthAdminMiddleware(), middlewares.IsAdminClass())
+	protectedAdminClasses.Use(middlewares.Auth("admin"), middlewares.IsAdminClass(models.PermMarkAttendance), middlewares.ClassNotArchived())
+	{
+		protectedAdminClasses.POST("/markAttendance/:classId", admin_controller.MarkAttendance)
+	}
+
+	classStaff := r.Group("/classes/:classId/staff")
+	classStaff.Use(middlewares.Auth("admin"), middlewares.IsAdminClass())
+	{
+		classStaff.GET("", admin_controller.ListStaff)
+		classStaff.POST("/leave", admin_controller.LeaveStaff)
+	}
+
+	manageStaff := r.Group("/classes/:classId/staff")
+	manageStaff.Use(middlewares.Auth("admin"), middlewares.IsAdminClass(models.PermManageStaff))
+	{
+		manageStaff.POST("", admin_controller.InviteStaff)
+		manageStaff.DELETE("/:adminId", admin_controller.RemoveStaff)
+	}
+
+	classOwner := r.Group("/classes/:classId")
+	classOwner.Use(middlewares.Auth("admin"), middlewares.IsAdminClass(models.PermTransferClass))
+	{
+		classOwner.POST("/transfer", admin_controller.ProposeClassTransfer)
+		classOwner.DELETE("/transfer", admin_controller.CancelClassTransfer)
+	}
+
+	manageClass := r.Group("/classes/:classId")
+	manageClass.Use(middlewares.Auth("admin"), middlewares.IsAdminClass(models.PermManageClass))
+	{
+		manageClass.GET("/events", admin_controller.ClassEvents)
+		manageClass.PATCH("", admin_controller.UpdateClass)
+		manageClass.POST("/archive", admin_controller.ArchiveClass)
+		manageClass.POST("/restore", admin_controller.RestoreClass)
+	}
+
+	classCodes := r.Group("/classes/:classId/codes")
+	classCodes.Use(middlewares.Auth("admin"), middlewares.IsAdminClass(models.PermManageRoster))
+	{
+		classCodes.GET("", admin_controller.ListClassCodes)
+		classCodes.DELETE("/:codeId", admin_controller.RevokeClassCode)
+	}
+
+	activeClassCodes := r.Group("/classes/:classId/codes")
+	activeClassCodes.Use(middlewares.Auth("admin"), middlewares.IsAdminClass(models.PermManageRoster), middlewares.ClassNotArchived())
+	{
+		activeClassCodes.POST("", admin_controller.CreateClassCode)
+		activeClassCodes.POST("/:codeId/rotate", admin_controller.RotateClassCode)
+	}
+
+	readEnrollments := r.Group("/classes/:classId/enrollmentRequests")
+	readEnrollments.Use(middlewares.AdminOrToken(models.PATScopeRosterRead, models.PATScopeRosterManage), middlewares.IsAdminClass(models.PermViewRoster))
+	{
+		readEnrollments.GET("", admin_controller.ListEnrollmentRequests)
+	}
+
+	reviewEnrollments := r.Group("/classes/:classId/enrollmentRequests")
+	reviewEnrollments.Use(middlewares.AdminOrToken(models.PATScopeRosterManage), middlewares.IsAdminClass(models.PermManageRoster), middlewares.ClassNotArchived())
 	{
-		protected.GET("/quickSummary/:classId", admin_controller.QuickSummary)
-		protected.POST("/markAttendance/:classId", admin_controller.MarkAttendance)
-		protected.GET("/studentsList/:classId", admin_controller.StudentsList)
-		protected.GET("/streak/:classId", admin_controller.Streak)
-		protected.GET("/personalSummary/:classId", admin_controller.PersonalSummary)
+		reviewEnrollments.POST("/approve", admin_controller.ApproveEnrollmentRequests)
+		reviewEnrollments.POST("/reject", admin_controller.RejectEnrollmentRequests)
+	}
+
+	manageStudents := r.Group("/classes/:classId/students")
+	manageStudents.Use(middlewares.AdminOrToken(models.PATScopeRosterManage), middlewares.IsAdminClass(models.PermManageRoster), middlewares.ClassNotArchived())
+	{
+		manageStudents.DELETE("/:userId", admin_controller.RemoveStudent)
+	}
 
+	rosterImport := r.Group("/classes/:classId/roster")
+	rosterImport.Use(middlewares.AdminOrToken(models.PATScopeRosterManage), middlewares.IsAdminClass(models.PermManageRoster), middlewares.ClassNotArchived())
+	{
+		rosterImport.POST("/import", admin_controller.ImportRoster)
+	}
+
+	classInvites := r.Group("/classes/:classId/invites")
+	classInvites.Use(middlewares.AdminOrToken(models.PATScopeRosterManage), middlewares.IsAdminClass(models.PermManageRoster), middlewares.ClassNotArchived())
+	{
+		classInvites.POST("", admin_controller.CreateClassInvite)
+		classInvites.DELETE("", admin_controller.RevokeClassInvites)
+	}
+
+	deleteClass := r.Group("/classes/:classId")
+	deleteClass.Use(middlewares.Auth("admin"), middlewares.IsAdminClass(models.PermDeleteClass))
+	{
+		deleteClass.DELETE("", admin_controller.DeleteClass)
 	}
 }
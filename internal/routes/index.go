@@ -3,6 +3,7 @@ package routes
 import (
 	"github.com/gin-gonic/gin"
 
+	auth_controller "github.com/hyphenXY/Streak-App/internal/controllers/auth"
 	"github.com/hyphenXY/Streak-App/internal/routes/admin"
 	"github.com/hyphenXY/Streak-App/internal/routes/root"
 	"github.com/hyphenXY/Streak-App/internal/routes/user"
@@ -18,6 +19,9 @@ func SetupRouter() *gin.Engine {
 	r.Use(middlewares.CORSMiddleware())
 	// etc.
 
+	// public keys for verifying our access tokens
+	r.GET("/.well-known/jwks.json", auth_controller.JWKS)
+
 	// root-level routes (no prefix)
 	rootGroup := r.Group("/root")
 	root_routes.RegisterRootRoutes(rootGroup)
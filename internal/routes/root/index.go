@@ -2,21 +2,46 @@ package root_routes
 
 import (
 	"github.com/gin-gonic/gin"
+	auth_controller "github.com/hyphenXY/Streak-App/internal/controllers/auth"
 	"github.com/hyphenXY/Streak-App/internal/controllers/root"
 	"github.com/hyphenXY/Streak-App/internal/middleware"
 )
 
 func RegisterRootRoutes(r *gin.RouterGroup) {
 	r.POST("/signIn", root_controller.SignIn)
+	r.POST("/signIn/2fa", root_controller.SignInTwoFactor)
 	r.POST("/register", root_controller.Register)
+	r.POST("/refreshToken", root_controller.RefreshTokenRoot)
 	r.GET("/health-check", root_controller.HealthCheck)
 
 	protected := r.Group("")
-	protected.Use(middlewares.AuthRootMiddleware())
+	protected.Use(middlewares.Auth("root"))
 	{
 	protected.GET("/homepage/:id", root_controller.Homepage)
 	protected.GET("/profile/:id", root_controller.Profile)
 	protected.PATCH("/profile/:id", root_controller.UpdateProfile)
+	protected.GET("/admins", root_controller.ListAdmins)
+	protected.POST("/admin/:id/suspend", root_controller.SuspendAdmin)
+	protected.POST("/admin/:id/reinstate", root_controller.ReinstateAdmin)
 	protected.DELETE("/admin/:id", root_controller.DeleteAdmin)
+	protected.POST("/invite", root_controller.Invite)
+	protected.POST("/logOutRoot", root_controller.LogOutRoot)
+	protected.GET("/sessions", auth_controller.ListSessions)
+	protected.DELETE("/sessions/:sessionId", auth_controller.RevokeSession)
+	protected.POST("/sessions/revokeOthers", auth_controller.RevokeOtherSessions)
+	protected.POST("/changePassword", auth_controller.ChangePassword)
+	protected.POST("/changePhone/sendOTP", auth_controller.SendChangePhoneOTP)
+	protected.POST("/changePhone", auth_controller.ChangePhone)
+	protected.GET("/2fa", auth_controller.TwoFactorStatus)
+	protected.POST("/2fa/setup", auth_controller.SetupTwoFactor)
+	protected.POST("/2fa/enable", auth_controller.EnableTwoFactor)
+	protected.POST("/2fa/disable", auth_controller.DisableTwoFactor)
+	protected.POST("/2fa/recoveryCodes", auth_controller.RegenerateRecoveryCodes)
+	protected.GET("/authEvents", root_controller.ListAuthEvents)
+	protected.GET("/settings", root_controller.Settings)
+	protected.PATCH("/settings", root_controller.UpdateSettings)
+	protected.POST("/classes/:id/transfer", root_controller.ProposeClassTransfer)
+	protected.DELETE("/classes/:id/transfer", root_controller.CancelClassTransfer)
+	protected.GET("/classes/:id/events", root_controller.ClassEvents)
 	}
 }
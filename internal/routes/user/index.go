@@ -2,6 +2,7 @@ package user_routes
 
 import (
 	"github.com/gin-gonic/gin"
+	auth_controller "github.com/hyphenXY/Streak-App/internal/controllers/auth"
 	user_controller "github.com/hyphenXY/Streak-App/internal/controllers/user"
 	middlewares "github.com/hyphenXY/Streak-App/internal/middleware"
 )
@@ -9,28 +10,51 @@ import (
 func RegisterUserRoutes(r *gin.RouterGroup) {
 	// Public routes
 	r.POST("/signIn", user_controller.SignIn)
+	r.POST("/signIn/otp/send", user_controller.SendSignInOTP)
+	r.POST("/signIn/otp", user_controller.SignInWithOTP)
 	r.POST("/signUp", user_controller.SignUp)
 	r.POST("/sendOTP", user_controller.SendOTP)
 	r.POST("/verifyOTP", user_controller.VerifyOTP)
+	r.POST("/forgotPassword", user_controller.ForgotPassword)
+	r.POST("/forgotPassword/verify", user_controller.VerifyResetOTP)
+	r.POST("/resetPassword", user_controller.ResetPassword)
 	r.POST("/refreshToken", user_controller.RefreshTokenUser)
 
 	// Protected routes
 	protectedUserClasses := r.Group("")
-	protectedUserClasses.Use(middlewares.AuthUserMiddleware(), middlewares.IsUserClass())
+	protectedUserClasses.Use(middlewares.Auth("user"), middlewares.IsUserClass())
 	{
-		protectedUserClasses.POST("/markAttendance/:classID", user_controller.MarkAttendance)
 		protectedUserClasses.GET("/classDetails/:classID", user_controller.ClassDetails)
 		protectedUserClasses.GET("/calendar/:classID", user_controller.Calendar)
 		protectedUserClasses.GET("/streak/:classID", user_controller.Streak)
 		protectedUserClasses.GET("/quickSummary/:classID", user_controller.QuickSummary)
 	}
 
+	activeUserClasses := r.Group("")
+	activeUserClasses.Use(middlewares.Auth("user"), middlewares.IsUserClass(), middlewares.ClassNotArchived())
+	{
+		activeUserClasses.POST("/markAttendance/:classID", user_controller.MarkAttendance)
+		activeUserClasses.POST("/leaveClass/:classID", user_controller.LeaveClass)
+	}
+
 	protectedUser := r.Group("")
-	protectedUser.Use(middlewares.AuthUserMiddleware())
+	protectedUser.Use(middlewares.Auth("user"))
 	{
-		protectedUserClasses.POST("/enroll/:classCode", user_controller.Enroll)
-		protectedUserClasses.GET("/classList", user_controller.ClassList)
+		protectedUser.POST("/enroll/:classCode", user_controller.Enroll)
+		protectedUser.POST("/enroll/invite/:token", user_controller.EnrollWithInvite)
+		protectedUser.GET("/classList", user_controller.ClassList)
+		protectedUser.GET("/enrollmentRequests", user_controller.EnrollmentRequests)
 		protectedUser.POST("/logOutUser", user_controller.LogOutUser)
+		protectedUser.GET("/sessions", auth_controller.ListSessions)
+		protectedUser.DELETE("/sessions/:sessionId", auth_controller.RevokeSession)
+		protectedUser.POST("/sessions/revokeOthers", auth_controller.RevokeOtherSessions)
+		protectedUser.GET("/authEvents", auth_controller.ListAuthEvents)
+		protectedUser.POST("/email/sendVerification", auth_controller.SendEmailVerification)
+		protectedUser.POST("/email/verify", auth_controller.VerifyEmail)
+		protectedUser.PUT("/otpChannel", auth_controller.SetOTPChannel)
+		protectedUser.POST("/changePassword", auth_controller.ChangePassword)
+		protectedUser.POST("/changePhone/sendOTP", auth_controller.SendChangePhoneOTP)
+		protectedUser.POST("/changePhone", auth_controller.ChangePhone)
 		protectedUser.PATCH("/profile/:id", user_controller.UpdateProfile)
 		protectedUser.GET("/profile", user_controller.Profile)
 
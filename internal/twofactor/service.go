@@ -0,0 +1,299 @@
+// Package twofactor implements TOTP (RFC 6238) second factors and recovery
+// codes for admin and root sign-in.
+package twofactor
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	dataprovider "github.com/hyphenXY/Streak-App/internal/dataproviders"
+	"github.com/hyphenXY/Streak-App/internal/models"
+	"github.com/hyphenXY/Streak-App/internal/utils"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+	"gorm.io/gorm"
+)
+
+const (
+	// Issuer is the name authenticator apps show next to the account.
+	Issuer = "Streak"
+	// ChallengeTTL is how long a sign-in has to present its second factor.
+	ChallengeTTL = 5 * time.Minute
+	// MaxChallengeAttempts wrong codes burn a challenge; sign in again.
+	MaxChallengeAttempts = 5
+	RecoveryCodeCount    = 10
+
+	period = 30 // seconds per TOTP step
+	skew   = 1  // steps of clock drift accepted either side
+)
+
+var (
+	ErrAlreadyEnabled   = errors.New("two-factor authentication already enabled")
+	ErrNotEnrolled      = errors.New("two-factor authentication not set up")
+	ErrInvalidCode      = errors.New("invalid two-factor code")
+	ErrInvalidChallenge = dataprovider.ErrTwoFactorChallenge
+	ErrTooManyAttempts  = dataprovider.ErrTwoFactorChallengeMax
+)
+
+// recoveryAlphabet leaves out look-alike characters.
+const recoveryAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+// Enabled reports whether the subject finished TOTP setup.
+func Enabled(subjectID uint, role string) (bool, error) {
+	tf, err := dataprovider.GetTwoFactor(subjectID, role)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return tf.EnabledAt != nil, nil
+}
+
+// Required reports whether every account of role must use two-factor
+// authentication. Root turns this on for admins.
+func Required(role string) (bool, error) {
+	if role != "admin" {
+		return false, nil
+	}
+	return dataprovider.GetBoolSetting(models.SettingRequireAdmin2FA)
+}
+
+// Setup generates a new TOTP secret for the subject and returns it with the
+// otpauth:// URI to show as a QR code. It stays inactive until Enable.
+func Setup(subjectID uint, role string) (secret string, uri string, err error) {
+	enabled, err := Enabled(subjectID, role)
+	if err != nil {
+		return "", "", err
+	}
+	if enabled {
+		return "", "", ErrAlreadyEnabled
+	}
+
+	accountName, err := dataprovider.GetUserNameByRole(subjectID, role)
+	if err != nil {
+		return "", "", err
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      Issuer,
+		AccountName: accountName,
+		Period:      period,
+		Digits:      otp.DigitsSix,
+		Algorithm:   otp.AlgorithmSHA1,
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := dataprovider.SaveTwoFactorSecret(subjectID, role, key.Secret()); err != nil {
+		return "", "", err
+	}
+	return key.Secret(), key.URL(), nil
+}
+
+// Enable confirms setup with a first code from the authenticator app and
+// returns a fresh set of recovery codes. They are only ever shown here.
+func Enable(subjectID uint, role string, code string) ([]string, error) {
+	tf, err := dataprovider.GetTwoFactor(subjectID, role)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotEnrolled
+	}
+	if err != nil {
+		return nil, err
+	}
+	if tf.EnabledAt != nil {
+		return nil, ErrAlreadyEnabled
+	}
+
+	step, ok := matchTOTP(tf.Secret, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidCode
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := dataprovider.EnableTwoFactor(tf, step, hashes); err != nil {
+		if errors.Is(err, dataprovider.ErrTOTPCodeReused) {
+			return nil, ErrInvalidCode
+		}
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Check accepts either a current TOTP code or an unused recovery code for an
+// enabled subject. Each code works only once.
+func Check(subjectID uint, role string, code string) error {
+	tf, err := dataprovider.GetTwoFactor(subjectID, role)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotEnrolled
+	}
+	if err != nil {
+		return err
+	}
+	if tf.EnabledAt == nil {
+		return ErrNotEnrolled
+	}
+
+	code = normalizeCode(code)
+	if len(code) == int(otp.DigitsSix) {
+		step, ok := matchTOTP(tf.Secret, code, time.Now())
+		if !ok {
+			return ErrInvalidCode
+		}
+		if err := dataprovider.UseTOTPStep(tf, step); err != nil {
+			if errors.Is(err, dataprovider.ErrTOTPCodeReused) {
+				return ErrInvalidCode
+			}
+			return err
+		}
+		return nil
+	}
+
+	if err := dataprovider.UseRecoveryCode(subjectID, role, utils.HashToken(code)); err != nil {
+		if errors.Is(err, dataprovider.ErrRecoveryCodeInvalid) {
+			return ErrInvalidCode
+		}
+		return err
+	}
+	return nil
+}
+
+// Disable checks code and removes the subject's TOTP enrollment.
+func Disable(subjectID uint, role string, code string) error {
+	if err := Check(subjectID, role, code); err != nil {
+		return err
+	}
+	return dataprovider.DeleteTwoFactor(subjectID, role)
+}
+
+// RegenerateRecoveryCodes checks code and replaces all recovery codes.
+func RegenerateRecoveryCodes(subjectID uint, role string, code string) ([]string, error) {
+	if err := Check(subjectID, role, code); err != nil {
+		return nil, err
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := dataprovider.ReplaceRecoveryCodes(subjectID, role, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// NewChallenge opens the second sign-in step for a subject whose password was
+// correct and returns its token.
+func NewChallenge(subjectID uint, role string, deviceName string) (string, error) {
+	token, err := utils.GenerateRefreshToken()
+	if err != nil {
+		return "", err
+	}
+	err = dataprovider.CreateTwoFactorChallenge(&models.TwoFactorChallenge{
+		SubjectID:  subjectID,
+		Role:       role,
+		TokenHash:  utils.HashToken(token),
+		DeviceName: deviceName,
+		Expiry:     time.Now().Add(ChallengeTTL),
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// SetupForChallenge is Setup for a subject who has to enroll before their
+// sign-in can finish, identified by the challenge token.
+func SetupForChallenge(token string, role string) (secret string, uri string, err error) {
+	challenge, err := dataprovider.AttemptTwoFactorChallenge(utils.HashToken(token), role, MaxChallengeAttempts)
+	if err != nil {
+		return "", "", err
+	}
+	return Setup(challenge.SubjectID, role)
+}
+
+// AnswerChallenge checks the code for a challenge and uses it up. If the
+// subject was made to enroll during this sign-in, the code confirms setup
+// and the new recovery codes are returned as well. The challenge is also
+// returned with ErrInvalidCode, so the failure can be put on record.
+func AnswerChallenge(token string, role string, code string) (*models.TwoFactorChallenge, []string, error) {
+	challenge, err := dataprovider.AttemptTwoFactorChallenge(utils.HashToken(token), role, MaxChallengeAttempts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	enabled, err := Enabled(challenge.SubjectID, role)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var recoveryCodes []string
+	if enabled {
+		err = Check(challenge.SubjectID, role, code)
+	} else {
+		recoveryCodes, err = Enable(challenge.SubjectID, role, code)
+	}
+	if err != nil {
+		return challenge, nil, err
+	}
+
+	if err := dataprovider.CompleteTwoFactorChallenge(challenge); err != nil {
+		return nil, nil, err
+	}
+	return challenge, recoveryCodes, nil
+}
+
+// matchTOTP looks for code within the accepted clock skew of now and returns
+// the time step it belongs to.
+func matchTOTP(secret string, code string, now time.Time) (int64, bool) {
+	code = normalizeCode(code)
+	for i := -skew; i <= skew; i++ {
+		t := now.Add(time.Duration(i*period) * time.Second)
+		expected, err := totp.GenerateCodeCustom(secret, t, totp.ValidateOpts{
+			Period:    period,
+			Digits:    otp.DigitsSix,
+			Algorithm: otp.AlgorithmSHA1,
+		})
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return t.Unix() / period, true
+		}
+	}
+	return 0, false
+}
+
+// newRecoveryCodes returns RecoveryCodeCount codes formatted as xxxxx-xxxxx
+// along with the hashes to store.
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, RecoveryCodeCount)
+	hashes := make([]string, 0, RecoveryCodeCount)
+	alphabetSize := big.NewInt(int64(len(recoveryAlphabet)))
+	for range RecoveryCodeCount {
+		raw := make([]byte, 10)
+		for i := range raw {
+			n, err := rand.Int(rand.Reader, alphabetSize)
+			if err != nil {
+				return nil, nil, err
+			}
+			raw[i] = recoveryAlphabet[n.Int64()]
+		}
+		codes = append(codes, string(raw[:5])+"-"+string(raw[5:]))
+		hashes = append(hashes, utils.HashToken(string(raw)))
+	}
+	return codes, hashes, nil
+}
+
+func normalizeCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
@@ -0,0 +1,123 @@
+package twofactor
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyphenXY/Streak-App/internal/utils"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+const testSecret = "JBSWY3DPEHPK3PXP"
+
+func codeAt(t *testing.T, at time.Time) string {
+	t.Helper()
+	code, err := totp.GenerateCodeCustom(testSecret, at, totp.ValidateOpts{
+		Period:    period,
+		Digits:    otp.DigitsSix,
+		Algorithm: otp.AlgorithmSHA1,
+	})
+	if err != nil {
+		t.Fatalf("generating code: %v", err)
+	}
+	return code
+}
+
+func TestMatchTOTP(t *testing.T) {
+	// First second of a step, so one second earlier is already the previous one
+	now := time.Unix(1_700_000_010, 0)
+	step := now.Unix() / period
+
+	tests := []struct {
+		name     string
+		code     string
+		wantStep int64
+		wantOK   bool
+	}{
+		{name: "current step", code: codeAt(t, now), wantStep: step, wantOK: true},
+		{name: "last second of previous step", code: codeAt(t, now.Add(-time.Second)), wantStep: step - 1, wantOK: true},
+		{name: "start of previous step", code: codeAt(t, now.Add(-period*time.Second)), wantStep: step - 1, wantOK: true},
+		{name: "next step", code: codeAt(t, now.Add(period*time.Second)), wantStep: step + 1, wantOK: true},
+		{name: "last second of next step", code: codeAt(t, now.Add(2*period*time.Second-time.Second)), wantStep: step + 1, wantOK: true},
+		{name: "two steps back", code: codeAt(t, now.Add(-period*time.Second-time.Second)), wantOK: false},
+		{name: "two steps ahead", code: codeAt(t, now.Add(2*period*time.Second)), wantOK: false},
+		{name: "spaced and dashed", code: " " + codeAt(t, now)[:3] + "-" + codeAt(t, now)[3:] + " ", wantStep: step, wantOK: true},
+		{name: "empty", code: "", wantOK: false},
+		{name: "too short", code: codeAt(t, now)[:5], wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotStep, ok := matchTOTP(testSecret, tt.code, now)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && gotStep != tt.wantStep {
+				t.Errorf("step = %d, want %d", gotStep, tt.wantStep)
+			}
+		})
+	}
+}
+
+func TestMatchTOTPInvalidSecret(t *testing.T) {
+	if _, ok := matchTOTP("not base32!", "123456", time.Now()); ok {
+		t.Fatal("matched a code against an invalid secret")
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(codes) != RecoveryCodeCount || len(hashes) != RecoveryCodeCount {
+		t.Fatalf("got %d codes and %d hashes, want %d of each", len(codes), len(hashes), RecoveryCodeCount)
+	}
+
+	format := regexp.MustCompile(`^[` + recoveryAlphabet + `]{5}-[` + recoveryAlphabet + `]{5}$`)
+	seen := make(map[string]bool)
+	for i, code := range codes {
+		if !format.MatchString(code) {
+			t.Errorf("code %q is not formatted as xxxxx-xxxxx", code)
+		}
+		if seen[code] {
+			t.Errorf("code %q issued twice", code)
+		}
+		seen[code] = true
+
+		// Codes are typed back in however the user copied them
+		for _, typed := range []string{
+			code,
+			strings.ToUpper(code),
+			strings.ReplaceAll(code, "-", ""),
+			" " + strings.ReplaceAll(code, "-", " ") + " ",
+		} {
+			if got := utils.HashToken(normalizeCode(typed)); got != hashes[i] {
+				t.Errorf("typed %q hashes to %s, want %s", typed, got, hashes[i])
+			}
+		}
+	}
+}
+
+func TestNormalizeCode(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "123456", want: "123456"},
+		{in: " 123 456 ", want: "123456"},
+		{in: "123-456", want: "123456"},
+		{in: "ABCDE-FGHJK", want: "abcdefghjk"},
+		{in: "abcde fghjk", want: "abcdefghjk"},
+		{in: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := normalizeCode(tt.in); got != tt.want {
+			t.Errorf("normalizeCode(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -2,64 +2,56 @@ package utils
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"math/big"
-	"os"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-var jwtSecret = []byte(os.Getenv("JWT_SECRET"))
-
-func ValidateJWT(tokenString string, requiredRole string) (map[string]interface{}, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return jwtSecret, nil
-	})
-
-	if err != nil {
-		return nil, err
-	}
-
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		// ✅ Check expiry
-		if exp, ok := claims["exp"].(float64); ok {
-			if int64(exp) < time.Now().Unix() {
-				return nil, errors.New("token expired")
-			}
-		}
-
-		// ✅ Check role
-		if role, ok := claims["role"].(string); ok {
-			if role != requiredRole {
-				return nil, errors.New("forbidden: role mismatch")
-			}
-		} else {
-			return nil, errors.New("role not found in token")
-		}
+// Claims is the payload of every access token we issue.
+type Claims struct {
+	UserID    uint   `json:"userId"`
+	Role      string `json:"role"`
+	SessionID uint   `json:"sessionId,omitempty"`
+	jwt.RegisteredClaims
+}
 
-		return claims, nil
-	}
+var (
+	ErrTokenExpired = errors.New("token expired")
+	ErrTokenInvalid = errors.New("invalid token")
+)
 
-	return nil, errors.New("invalid token")
+// GenerateAccessToken issues a 15 minute access token for the given session.
+func GenerateAccessToken(userID uint, role string, sessionID uint) (string, error) {
+	now := time.Now()
+	return signJWT(Claims{
+		UserID:    userID,
+		Role:      role,
+		SessionID: sessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(15 * time.Minute)), // expires in 15m
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	})
 }
 
-func GenerateJWT(claimsMap map[string]interface{}) (string, error) {
-	claims := jwt.MapClaims{
-		"exp": time.Now().Add(time.Minute * 15).Unix(), // expires in 15m
-		"iat": time.Now().Unix(),
+// ParseAccessToken verifies tokenString and returns its claims. Expired
+// tokens yield ErrTokenExpired; anything else wrong yields ErrTokenInvalid.
+func ParseAccessToken(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, jwtVerificationKey, jwt.WithExpirationRequired())
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return nil, ErrTokenExpired
 	}
-	for k, v := range claimsMap {
-		claims[k] = v
+	if err != nil || claims.UserID == 0 || claims.Role == "" {
+		return nil, ErrTokenInvalid
 	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
+	return claims, nil
 }
 
 func GenerateRefreshToken() (string, error) {
@@ -82,3 +74,23 @@ func GenerateOTP() (string, error) {
 	}
 	return otp, nil
 }
+
+// HashToken returns the hex SHA-256 of an opaque token, for storing tokens
+// without keeping them in plaintext.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// PersonalAccessTokenPrefix marks personal access tokens so they can be told
+// apart from JWTs, and recognised by secret scanners.
+const PersonalAccessTokenPrefix = "stk_pat_"
+
+// GeneratePersonalAccessToken returns a new random personal access token.
+func GeneratePersonalAccessToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return PersonalAccessTokenPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -2,16 +2,83 @@ package utils
 
 import (
 	"crypto/rand"
-	"encoding/base32"
+	"errors"
+	"math/big"
 	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
 )
 
-func GenerateRandomDigits(n int) string {
-	// Generate random bytes
-	b := make([]byte, n)
-	_, _ = rand.Read(b)
+// classCodeAlphabet leaves out characters that are easy to confuse when read
+// aloud or off a board: 0/O, 1/I/L.
+const classCodeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+
+const ClassCodeLength = 8
+
+// GenerateClassCode returns a random class code from classCodeAlphabet.
+func GenerateClassCode() (string, error) {
+	code := make([]byte, ClassCodeLength)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(classCodeAlphabet))))
+		if err != nil {
+			return "", err
+		}
+		code[i] = classCodeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
+
+// NormalizeClassCode uppercases a code as typed by a student and drops the
+// spaces and dashes they may have copied along.
+func NormalizeClassCode(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	return strings.NewReplacer(" ", "", "-", "").Replace(code)
+}
+
+// classInviteAudience keeps invite tokens and access tokens from being
+// accepted in place of each other.
+const classInviteAudience = "class_invite"
+
+// ClassInviteClaims is the payload of a class invite token.
+type ClassInviteClaims struct {
+	ClassID  uint `json:"classId"`
+	IssuedBy uint `json:"issuedBy"`
+	Epoch    uint `json:"epoch"`
+	jwt.RegisteredClaims
+}
+
+// GenerateClassInviteToken issues a signed invite to classID that expires at
+// expiresAt. epoch is the class's current invite epoch.
+func GenerateClassInviteToken(classID uint, adminID uint, epoch uint, expiresAt time.Time) (string, error) {
+	jti, err := GenerateRefreshToken()
+	if err != nil {
+		return "", err
+	}
+	return signJWT(ClassInviteClaims{
+		ClassID:  classID,
+		IssuedBy: adminID,
+		Epoch:    epoch,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Audience:  jwt.ClaimStrings{classInviteAudience},
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	})
+}
 
-	// Encode into base32 (letters + digits), cut to required length
-	code := strings.ToLower(base32.StdEncoding.EncodeToString(b))
-	return code[:n]
+// ParseClassInviteToken verifies an invite token. Expired invites yield
+// ErrTokenExpired; anything else wrong yields ErrTokenInvalid.
+func ParseClassInviteToken(tokenString string) (*ClassInviteClaims, error) {
+	claims := &ClassInviteClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, jwtVerificationKey,
+		jwt.WithExpirationRequired(), jwt.WithAudience(classInviteAudience))
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return nil, ErrTokenExpired
+	}
+	if err != nil || claims.ClassID == 0 || claims.IssuedBy == 0 {
+		return nil, ErrTokenInvalid
+	}
+	return claims, nil
 }
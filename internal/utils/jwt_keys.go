@@ -0,0 +1,181 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// jwtKey is one key from JWT_KEYS_DIR. Keys loaded from a private key file
+// can sign; keys loaded from a public key file only verify, which is how a
+// rotated-out key stays valid until the tokens it signed expire.
+type jwtKey struct {
+	kid     string
+	method  jwt.SigningMethod
+	private crypto.Signer
+	public  crypto.PublicKey
+}
+
+var (
+	jwtKeys       map[string]*jwtKey
+	jwtSigningKey *jwtKey
+)
+
+// InitJWTKeys loads every *.pem file in JWT_KEYS_DIR as a JWT key, using the
+// file name without extension as its kid. RSA keys sign with RS256 and
+// Ed25519 keys with EdDSA. JWT_SIGNING_KID picks the signing key; it may be
+// omitted when the directory holds exactly one private key.
+func InitJWTKeys() error {
+	dir := os.Getenv("JWT_KEYS_DIR")
+	if dir == "" {
+		return errors.New("JWT_KEYS_DIR is not set")
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return err
+	}
+
+	keys := make(map[string]*jwtKey, len(paths))
+	var signers []*jwtKey
+	for _, path := range paths {
+		kid := strings.TrimSuffix(filepath.Base(path), ".pem")
+		key, err := loadJWTKey(kid, path)
+		if err != nil {
+			return fmt.Errorf("loading JWT key %s: %w", path, err)
+		}
+		keys[kid] = key
+		if key.private != nil {
+			signers = append(signers, key)
+		}
+	}
+
+	var signing *jwtKey
+	if kid := os.Getenv("JWT_SIGNING_KID"); kid != "" {
+		signing = keys[kid]
+		if signing == nil || signing.private == nil {
+			return fmt.Errorf("JWT_SIGNING_KID %q has no private key in %s", kid, dir)
+		}
+	} else {
+		if len(signers) != 1 {
+			return fmt.Errorf("found %d private keys in %s; set JWT_SIGNING_KID", len(signers), dir)
+		}
+		signing = signers[0]
+	}
+
+	jwtKeys = keys
+	jwtSigningKey = signing
+	return nil
+}
+
+func loadJWTKey(kid string, path string) (*jwtKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var parsed any
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &jwtKey{kid: kid}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.method, key.private, key.public = jwt.SigningMethodRS256, k, &k.PublicKey
+	case ed25519.PrivateKey:
+		key.method, key.private, key.public = jwt.SigningMethodEdDSA, k, k.Public()
+	case *rsa.PublicKey:
+		key.method, key.public = jwt.SigningMethodRS256, k
+	case ed25519.PublicKey:
+		key.method, key.public = jwt.SigningMethodEdDSA, k
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+	return key, nil
+}
+
+// signJWT signs claims with the current signing key and stamps its kid.
+func signJWT(claims jwt.Claims) (string, error) {
+	if jwtSigningKey == nil {
+		return "", errors.New("JWT keys not initialized")
+	}
+	token := jwt.NewWithClaims(jwtSigningKey.method, claims)
+	token.Header["kid"] = jwtSigningKey.kid
+	return token.SignedString(jwtSigningKey.private)
+}
+
+// jwtVerificationKey resolves the key a token was signed with from its kid
+// header, and refuses tokens whose alg doesn't match that key.
+func jwtVerificationKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := jwtKeys[kid]
+	if !ok {
+		return nil, errors.New("unknown signing key")
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, errors.New("unexpected signing method")
+	}
+	return key.public, nil
+}
+
+// JWKS returns the public half of every loaded key as a JSON Web Key Set.
+func JWKS() map[string]any {
+	kids := make([]string, 0, len(jwtKeys))
+	for kid := range jwtKeys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	keys := make([]map[string]any, 0, len(kids))
+	for _, kid := range kids {
+		key := jwtKeys[kid]
+		switch pub := key.public.(type) {
+		case *rsa.PublicKey:
+			keys = append(keys, map[string]any{
+				"kty": "RSA",
+				"kid": kid,
+				"use": "sig",
+				"alg": key.method.Alg(),
+				"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			keys = append(keys, map[string]any{
+				"kty": "OKP",
+				"crv": "Ed25519",
+				"kid": kid,
+				"use": "sig",
+				"alg": key.method.Alg(),
+				"x":   base64.RawURLEncoding.EncodeToString(pub),
+			})
+		}
+	}
+	return map[string]any{"keys": keys}
+}
@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/nyaruka/phonenumbers"
+)
+
+var ErrInvalidPhone = errors.New("invalid phone number")
+
+// defaultPhoneRegion is assumed for numbers given without a country code.
+// Streak started out India-only, so legacy clients send bare 10-digit numbers.
+const defaultPhoneRegion = "IN"
+
+// NormalizePhone validates a phone number and returns it in E.164 form, e.g.
+// "+919876543210". Numbers without a "+" country code are read as national
+// numbers of PHONE_DEFAULT_REGION (an ISO 3166 code, default IN), so a
+// trunk prefix such as a leading 0 is handled too.
+func NormalizePhone(raw string) (string, error) {
+	region := strings.ToUpper(strings.TrimSpace(os.Getenv("PHONE_DEFAULT_REGION")))
+	if region == "" {
+		region = defaultPhoneRegion
+	}
+
+	num, err := phonenumbers.Parse(strings.TrimSpace(raw), region)
+	if err != nil || !phonenumbers.IsValidNumber(num) {
+		return "", ErrInvalidPhone
+	}
+	return phonenumbers.Format(num, phonenumbers.E164), nil
+}
@@ -6,7 +6,10 @@ import (
 	"os"
 
 	dataprovider "github.com/hyphenXY/Streak-App/internal/dataproviders"
+	"github.com/hyphenXY/Streak-App/internal/mail"
+	"github.com/hyphenXY/Streak-App/internal/otp"
 	"github.com/hyphenXY/Streak-App/internal/routes"
+	"github.com/hyphenXY/Streak-App/internal/utils"
 	"github.com/joho/godotenv"
 )
 
@@ -14,11 +17,26 @@ func main() {
 	// Load .env file (only used locally)
 	_ = godotenv.Load("./.env")
 
+	// Load JWT signing and verification keys
+	if err := utils.InitJWTKeys(); err != nil {
+		log.Fatalf("❌ Could not load JWT keys: %v", err)
+	}
+
 	// Initialize DB (connect + migrate)
 	if err := dataprovider.InitDB(); err != nil {
 		log.Fatalf("❌ Could not initialize database: %v", err)
 	}
 
+	// Pick the OTP delivery provider
+	if err := otp.Init(); err != nil {
+		log.Fatalf("❌ Could not initialize OTP provider: %v", err)
+	}
+
+	// Pick the mail delivery provider
+	if err := mail.Init(); err != nil {
+		log.Fatalf("❌ Could not initialize mail provider: %v", err)
+	}
+
 	// Start the Gin router
 	r := routes.SetupRouter()
 